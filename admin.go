@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validateTemplates is the self-test run after templates are reloaded: every
+// page route's template and 404.html must load, every tutorial guide must
+// have a title, and the CLI reference must still parse.
+func validateTemplates(templates *assetCache, pages map[string]string) error {
+	var errs []error
+	names := append(slices.Collect(maps.Values(pages)), "404.html")
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		if _, err := templates.get(name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	for _, page := range tutorialPages {
+		file, err := templates.get(page.Template)
+		if err == nil && extractTutorialMeta(string(file.content)).Title == "" {
+			errs = append(errs, fmt.Errorf("%s: no <title>", page.Template))
+		}
+	}
+	if file, err := templates.get(cliReferenceTemplate); err == nil {
+		if _, err := parseCLICommands(string(file.content)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", cliReferenceTemplate, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reloadTemplates returns a reload hook that reads every template into a
+// staged set and validates it. The staged set only replaces the templates in
+// use when it passes; otherwise the current templates keep being served.
+func reloadTemplates(templates *assetCache, pages map[string]string) func() error {
+	return func() error {
+		err := templates.reload(func(staged *assetCache) error {
+			return validateTemplates(staged, pages)
+		})
+		if err != nil {
+			return fmt.Errorf("kept the current templates: %w", err)
+		}
+		return nil
+	}
+}
+
+// adminReloadHandler runs every reload hook, as SIGHUP does, and reports the
+// outcome of each. It answers 422 when any of them failed.
+func adminReloadHandler(reloads *reloader) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		results := reloads.run()
+
+		ok := true
+		for _, result := range results {
+			ok = ok && result.OK
+		}
+		status := fiber.StatusOK
+		if !ok {
+			status = fiber.StatusUnprocessableEntity
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"ok":       ok,
+			"reloaded": results,
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validTemplates returns a template set that passes validateTemplates.
+func validTemplates() fstest.MapFS {
+	templates := fstest.MapFS{"404.html": page("Not Found", "custom not found")}
+	for _, tmpl := range pageRoutes() {
+		templates[tmpl] = guide(tmpl)
+	}
+	templates[cliReferenceTemplate] = &fstest.MapFile{Data: []byte(cliReferenceCommands)}
+	return templates
+}
+
+func TestAdminReload(t *testing.T) {
+	templates := validTemplates()
+	templates["tutorial-tui.html"] = guide("TUI v1")
+	cfg := testRouteConfig(templates, fstest.MapFS{})
+	cfg.adminToken = "secret"
+	app := newTestApp(t, cfg)
+
+	reload := func(token string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+		req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, body := send(t, app, req)
+		return resp.StatusCode, body
+	}
+	served := func() string {
+		t.Helper()
+		_, body := getPage(t, app, "/tutorial/tui")
+		return body
+	}
+
+	if status, _ := reload(""); status != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want 401", status)
+	}
+
+	// Edits are not served until a reload
+	templates["tutorial-tui.html"] = guide("TUI v2")
+	if body := served(); !strings.Contains(body, "TUI v1") {
+		t.Fatalf("edit served before reload: %q", body)
+	}
+	status, body := reload("secret")
+	if status != http.StatusOK {
+		t.Fatalf("valid reload: status %d: %s", status, body)
+	}
+	if body := served(); !strings.Contains(body, "TUI v2") {
+		t.Errorf("after reload: %q, want TUI v2", body)
+	}
+
+	// A set failing validation is rejected as a whole
+	templates["tutorial-tui.html"] = &fstest.MapFile{Data: []byte("<html><body>TUI v3, no title</body></html>")}
+	templates["index.html"] = guide("Home v3")
+	status, body = reload("secret")
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid reload: status %d, want 422", status)
+	}
+	var report struct {
+		OK       bool           `json:"ok"`
+		Reloaded []reloadResult `json:"reloaded"`
+	}
+	if err := json.Unmarshal([]byte(body), &report); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	failed := ""
+	for _, result := range report.Reloaded {
+		if !result.OK {
+			failed += result.Name + ": " + result.Error
+		}
+	}
+	if report.OK || !strings.Contains(failed, "templates: kept the current templates: tutorial-tui.html: no <title>") {
+		t.Errorf("report %+v, want the templates reload to fail on the missing title", report)
+	}
+	if body := served(); !strings.Contains(body, "TUI v2") {
+		t.Errorf("after failed reload: %q, want TUI v2 still served", body)
+	}
+	if _, body := getPage(t, app, "/"); strings.Contains(body, "Home v3") {
+		t.Error("a valid file from the rejected set was served")
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// embeddedAssets holds the templates and static files compiled into the
+// binary, so it runs from any working directory.
+//
+//go:embed templates static
+var embeddedAssets embed.FS
+
+// assetFS returns the embedded dir, or the directory override on disk when it
+// is set so files can be edited without recompiling.
+func assetFS(dir, override string) (fs.FS, error) {
+	if override == "" {
+		return fs.Sub(embeddedAssets, dir)
+	}
+	info, err := os.Stat(override)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", override)
+	}
+	log.Printf("Serving %s from %s", dir, override)
+	return os.DirFS(override), nil
+}
+
+// asset is a file held in memory with the validators sent for it.
+type asset struct {
+	content []byte
+	etag    string
+	modTime time.Time // sent as Last-Modified
+	version time.Time // modification time reported by the file system
+}
+
+// assetCache keeps the files of an fs.FS in memory. With watch set, a file
+// is re-read whenever its modification time changes, so files under
+// STATIC_DIR are picked up as they are edited. Without it the cache serves
+// the set read by preload until reload replaces it; templates work this way
+// so an edit only goes live once the new set has been validated.
+type assetCache struct {
+	fsys     fs.FS
+	decode   func(name string, content []byte) []byte // set for templates
+	watch    bool
+	loadedAt time.Time
+
+	// serveStale keeps serving the cached copy of a file when re-reading it
+	// fails (SERVE_STALE_ON_ERROR): on request for a watched file, and on
+	// reload otherwise. A deleted file is gone, not failing, and is never
+	// served stale.
+	serveStale bool
+
+	mu    sync.Mutex
+	files map[string]asset
+	// stale holds the files being served stale, so each is logged once
+	stale map[string]bool
+
+	// reloadMu makes reloads run one at a time, so a slower, older read never
+	// replaces a newer set.
+	reloadMu sync.Mutex
+}
+
+func newAssetCache(fsys fs.FS, decode func(name string, content []byte) []byte, watch bool) *assetCache {
+	return &assetCache{
+		fsys:     fsys,
+		decode:   decode,
+		watch:    watch,
+		loadedAt: time.Now(),
+		files:    make(map[string]asset),
+		stale:    make(map[string]bool),
+	}
+}
+
+// preload reads every file up front, so problems with them are logged at
+// startup rather than on the first request.
+func (a *assetCache) preload() {
+	files, errs := a.readAll(nil)
+	for _, err := range errs {
+		log.Printf("Warning: failed to load %v", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files = files
+}
+
+// reload reads every file into a staged cache and hands it to validate. The
+// staged files replace the current ones in a single step, and only when all
+// of them were read and validate accepted them; otherwise the current files
+// stay in use and requests never see the rejected set. With serveStale, a
+// file that fails to read keeps its current copy in the staged set instead.
+func (a *assetCache) reload(validate func(staged *assetCache) error) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	var current map[string]asset
+	if a.serveStale {
+		a.mu.Lock()
+		current = maps.Clone(a.files)
+		a.mu.Unlock()
+	}
+	files, errs := a.readAll(current)
+	staged := &assetCache{fsys: a.fsys, decode: a.decode, loadedAt: a.loadedAt, files: files}
+	err := errors.Join(errs...)
+	if err == nil && validate != nil {
+		err = validate(staged)
+	}
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files = files
+	return nil
+}
+
+// readAll reads every file of the cache's file system into a new map. A file
+// that exists but fails to read is taken from fallback when it is there.
+func (a *assetCache) readAll(fallback map[string]asset) (map[string]asset, []error) {
+	files := make(map[string]asset)
+	var errs []error
+	err := fs.WalkDir(a.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := fs.Stat(a.fsys, name)
+		var file asset
+		if err == nil {
+			file, err = a.load(name, info.ModTime())
+		}
+		if prev, ok := fallback[name]; ok && err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Error reading %s, keeping last good version: %v", name, err)
+			file, err = prev, nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return nil
+		}
+		files[name] = file
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return files, errs
+}
+
+func (a *assetCache) get(name string) (asset, error) {
+	if !fs.ValidPath(name) {
+		return asset{}, fs.ErrNotExist
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cached, ok := a.files[name]
+	if !a.watch {
+		if !ok {
+			return asset{}, fs.ErrNotExist
+		}
+		return cached, nil
+	}
+
+	info, err := fs.Stat(a.fsys, name)
+	if err == nil && info.IsDir() {
+		return asset{}, fs.ErrNotExist
+	}
+	if err == nil && ok && cached.version.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	var file asset
+	if err == nil {
+		file, err = a.load(name, info.ModTime())
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		delete(a.files, name)
+		delete(a.stale, name)
+		return asset{}, err
+	}
+	if err != nil {
+		if ok && a.serveStale {
+			if !a.stale[name] {
+				log.Printf("Error refreshing %s, serving last good version: %v", name, err)
+				a.stale[name] = true
+			}
+			return cached, nil
+		}
+		return asset{}, err
+	}
+	a.files[name] = file
+	delete(a.stale, name)
+	return file, nil
+}
+
+// Open implements fs.FS over the cached files, so the cache can be handed to
+// code that takes a file system and be shared with it. Directories cannot be
+// opened.
+func (a *assetCache) Open(name string) (fs.File, error) {
+	file, err := a.get(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &cachedFile{Reader: bytes.NewReader(file.content), name: name, file: file}, nil
+}
+
+// cachedFile is an open file of an assetCache.
+type cachedFile struct {
+	*bytes.Reader
+	name string
+	file asset
+}
+
+func (f *cachedFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f *cachedFile) Close() error               { return nil }
+func (f *cachedFile) Name() string               { return filepath.Base(f.name) }
+func (f *cachedFile) Size() int64                { return int64(len(f.file.content)) }
+func (f *cachedFile) Mode() fs.FileMode          { return 0o444 }
+func (f *cachedFile) ModTime() time.Time         { return f.file.modTime }
+func (f *cachedFile) IsDir() bool                { return false }
+func (f *cachedFile) Sys() any                   { return nil }
+
+func (a *assetCache) load(name string, version time.Time) (asset, error) {
+	content, err := fs.ReadFile(a.fsys, name)
+	if err != nil {
+		return asset{}, err
+	}
+	if a.decode != nil {
+		if len(strings.TrimSpace(string(content))) == 0 {
+			return asset{}, errors.New("template is empty")
+		}
+		content = a.decode(name, content)
+	}
+
+	modTime := version
+	if modTime.IsZero() {
+		modTime = a.loadedAt
+	}
+	sum := sha256.Sum256(content)
+	return asset{
+		content: content,
+		etag:    fmt.Sprintf(`"%x"`, sum[:8]),
+		modTime: modTime,
+		version: version,
+	}, nil
+}
+
+// notModified sets the ETag and Last-Modified headers for file and reports
+// whether the client's cached copy is still current. When it is, the status
+// is set to 304 and no body should be sent.
+func notModified(c *fiber.Ctx, file asset) bool {
+	c.Set(fiber.HeaderETag, file.etag)
+	c.Set(fiber.HeaderLastModified, file.modTime.UTC().Format(http.TimeFormat))
+
+	fresh := false
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == file.etag {
+				fresh = true
+				break
+			}
+		}
+	} else if since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince)); err == nil {
+		fresh = !file.modTime.Truncate(time.Second).After(since)
+	}
+	if fresh {
+		c.Status(fiber.StatusNotModified)
+	}
+	return fresh
+}
+
+// assetTypes maps file extensions to the Content-Type they are served with.
+// Embedded files carry no type information, so it is resolved from the
+// extension alone, with MIME_TYPES taking precedence over this table.
+var assetTypes = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".mjs":   "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".svg":   "image/svg+xml",
+	".gif":   "image/gif",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".webp":  "image/webp",
+	".ico":   "image/x-icon",
+	".woff2": "font/woff2",
+	".txt":   "text/plain; charset=utf-8",
+	".html":  "text/html; charset=utf-8",
+}
+
+// parseMIMETypes parses ".ext=type/subtype,..." into a map of extension to
+// Content-Type, rejecting values that are not valid media types.
+func parseMIMETypes(value string) (map[string]string, error) {
+	types := make(map[string]string)
+	for _, def := range splitList(value) {
+		ext, contentType, ok := strings.Cut(def, "=")
+		ext, contentType = strings.ToLower(strings.TrimSpace(ext)), strings.TrimSpace(contentType)
+		if !ok || !strings.HasPrefix(ext, ".") {
+			return nil, fmt.Errorf("%q must be .ext=type/subtype", def)
+		}
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return nil, fmt.Errorf("%q has an invalid media type: %w", def, err)
+		}
+		types[ext] = contentType
+	}
+	return types, nil
+}
+
+// assetContentType resolves the Content-Type of an asset from its extension:
+// overrides first, then assetTypes, then the system MIME table. Unknown
+// extensions fall back to sniffing the content.
+func assetContentType(name string, content []byte, overrides map[string]string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if contentType, ok := overrides[ext]; ok {
+		return contentType
+	}
+	if contentType, ok := assetTypes[ext]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(content)
+}
+
+// staticRef matches src and href attributes and CSS url() values that point
+// into /static/, capturing the path below it without any query or fragment.
+var staticRef = regexp.MustCompile(`(?:(?:src|href)\s*=\s*["']|url\(\s*["']?)/static/([^"'?#)\s]+)`)
+
+// missingAssets scans every template for references to /static/ files and
+// returns a description of each one that the static directory does not have.
+func missingAssets(templates, static *assetCache) []string {
+	var missing []string
+	_ = fs.WalkDir(templates.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		file, err := templates.get(name)
+		if err != nil {
+			return nil
+		}
+		var refs []string
+		for _, match := range staticRef.FindAllStringSubmatch(string(file.content), -1) {
+			refs = append(refs, match[1])
+		}
+		slices.Sort(refs)
+		for _, ref := range slices.Compact(refs) {
+			if _, err := static.get(ref); err != nil {
+				missing = append(missing, fmt.Sprintf("%s references missing /static/%s", name, ref))
+			}
+		}
+		return nil
+	})
+	return missing
+}
+
+// staticHandler serves /static/* from memory, with Content-Types resolved by
+// assetContentType. Unknown files fall through to the 404 page.
+func staticHandler(static *assetCache, mimeTypes map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		name := c.Params("*")
+		file, err := static.get(name)
+		if err != nil {
+			return c.Next()
+		}
+		c.Set(fiber.HeaderContentType, assetContentType(name, file.content, mimeTypes))
+		if notModified(c, file) {
+			return nil
+		}
+		return c.Send(file.content)
+	}
+}
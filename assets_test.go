@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// unreadableFS is a MapFS whose listed files can be stat'ed but not read.
+type unreadableFS struct {
+	fstest.MapFS
+	unreadable map[string]bool
+}
+
+func (u unreadableFS) Open(name string) (fs.File, error) {
+	if u.unreadable[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return u.MapFS.Open(name)
+}
+
+func (u unreadableFS) ReadFile(name string) ([]byte, error) {
+	if u.unreadable[name] {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrPermission}
+	}
+	return u.MapFS.ReadFile(name)
+}
+
+// captureLog sends the standard logger to a buffer for the rest of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+	return &buf
+}
+
+func TestAssetCacheServesStaleOnReadError(t *testing.T) {
+	fsys := unreadableFS{
+		MapFS:      fstest.MapFS{"site.css": {Data: []byte("v1"), ModTime: time.Unix(1, 0)}},
+		unreadable: map[string]bool{},
+	}
+	static := newAssetCache(fsys, nil, true)
+	static.serveStale = true
+	logs := captureLog(t)
+
+	if file, err := static.get("site.css"); err != nil || string(file.content) != "v1" {
+		t.Fatalf("first get = %q, %v", file.content, err)
+	}
+
+	// The file changes but can no longer be read: keep the last good copy
+	fsys.MapFS["site.css"].ModTime = time.Unix(2, 0)
+	fsys.unreadable["site.css"] = true
+	for range 3 {
+		if file, err := static.get("site.css"); err != nil || string(file.content) != "v1" {
+			t.Errorf("get unreadable = %q, %v; want the stale v1", file.content, err)
+		}
+	}
+	if n := strings.Count(logs.String(), "serving last good version"); n != 1 {
+		t.Errorf("logged the stale file %d times, want once:\n%s", n, logs)
+	}
+
+	// Readable again: serve the new content and log the next failure afresh
+	fsys.MapFS["site.css"].Data = []byte("v2")
+	delete(fsys.unreadable, "site.css")
+	if file, err := static.get("site.css"); err != nil || string(file.content) != "v2" {
+		t.Errorf("get recovered = %q, %v; want v2", file.content, err)
+	}
+	fsys.MapFS["site.css"].ModTime = time.Unix(3, 0)
+	fsys.unreadable["site.css"] = true
+	static.get("site.css")
+	if n := strings.Count(logs.String(), "serving last good version"); n != 2 {
+		t.Errorf("logged the stale file %d times in total, want 2", n)
+	}
+}
+
+func TestAssetCacheDoesNotServeDeletedFiles(t *testing.T) {
+	fsys := fstest.MapFS{"site.css": {Data: []byte("v1")}}
+	static := newAssetCache(fsys, nil, true)
+	static.serveStale = true
+
+	if _, err := static.get("site.css"); err != nil {
+		t.Fatal(err)
+	}
+	delete(fsys, "site.css")
+	if _, err := static.get("site.css"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("get deleted file: error %v, want fs.ErrNotExist", err)
+	}
+	if _, ok := static.files["site.css"]; ok {
+		t.Error("deleted file is still cached")
+	}
+}
+
+func TestAssetCacheWithoutServeStale(t *testing.T) {
+	fsys := unreadableFS{
+		MapFS:      fstest.MapFS{"site.css": {Data: []byte("v1"), ModTime: time.Unix(1, 0)}},
+		unreadable: map[string]bool{},
+	}
+	static := newAssetCache(fsys, nil, true)
+	if _, err := static.get("site.css"); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.MapFS["site.css"].ModTime = time.Unix(2, 0)
+	fsys.unreadable["site.css"] = true
+	if _, err := static.get("site.css"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("get unreadable: error %v, want fs.ErrPermission", err)
+	}
+}
+
+func TestAssetCacheReloadKeepsUnreadableFiles(t *testing.T) {
+	for _, serveStale := range []bool{false, true} {
+		fsys := unreadableFS{
+			MapFS: fstest.MapFS{
+				"a.html": page("A", "a v1"),
+				"b.html": page("B", "b v1"),
+				"c.html": page("C", "c v1"),
+			},
+			unreadable: map[string]bool{},
+		}
+		templates := newAssetCache(fsys, decodeTemplate, false)
+		templates.serveStale = serveStale
+		templates.preload()
+		logs := captureLog(t)
+
+		// a is edited, b can no longer be read and c is deleted
+		fsys.MapFS["a.html"] = page("A", "a v2")
+		fsys.unreadable["b.html"] = true
+		delete(fsys.MapFS, "c.html")
+		err := templates.reload(nil)
+
+		content := func(name string) string {
+			file, err := templates.get(name)
+			if err != nil {
+				return err.Error()
+			}
+			return string(file.content)
+		}
+		if !serveStale {
+			if !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("without serveStale: reload error %v, want fs.ErrPermission", err)
+			}
+			if !strings.Contains(content("a.html"), "a v1") {
+				t.Error("without serveStale: a failed reload replaced the templates")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("with serveStale: reload error %v", err)
+		}
+		if got := content("a.html"); !strings.Contains(got, "a v2") {
+			t.Errorf("edited template %q, want a v2", got)
+		}
+		if got := content("b.html"); !strings.Contains(got, "b v1") {
+			t.Errorf("unreadable template %q, want the last good b v1", got)
+		}
+		if _, err := templates.get("c.html"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("deleted template: error %v, want fs.ErrNotExist", err)
+		}
+		if !strings.Contains(logs.String(), "Error reading b.html, keeping last good version") {
+			t.Errorf("stale template not logged:\n%s", logs)
+		}
+	}
+}
+
+func TestTemplateReloadServesStaleOnReadError(t *testing.T) {
+	templates := validTemplates()
+	templates["tutorial-tui.html"] = guide("TUI v1")
+	fsys := unreadableFS{MapFS: templates, unreadable: map[string]bool{}}
+	cfg := testRouteConfig(templates, fstest.MapFS{})
+	cfg.templates = fsys
+	cfg.serveStaleOnError = true
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	reloads := setupRoutes(app, cfg)
+	captureLog(t)
+
+	templates["tutorial-tui.html"] = guide("TUI v2")
+	fsys.unreadable["tutorial-tui.html"] = true
+	for _, result := range reloads.run() {
+		if !result.OK {
+			t.Errorf("reload of %s failed: %s", result.Name, result.Error)
+		}
+	}
+	if _, body := getPage(t, app, "/tutorial/tui"); !strings.Contains(body, "TUI v1") {
+		t.Errorf("unreadable guide after reload: %q, want the last good TUI v1", body)
+	}
+	if _, body := getPage(t, app, "/tutorial/all"); !strings.Contains(body, "TUI v1") {
+		t.Errorf("/tutorial/all after reload does not contain the last good TUI v1")
+	}
+}
+
+func TestStaticContentTypes(t *testing.T) {
+	static := fstest.MapFS{
+		"css/site.css":      {Data: []byte("body {}")},
+		"js/APP.JS":         {Data: []byte("console.log(1)")},
+		"img/logo.svg":      {Data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)},
+		"fonts/inter.woff2": {Data: []byte("wOF2")},
+		"img/blob":          {Data: []byte("\x89PNG\r\n\x1a\n")},
+		"js/app.js.map":     {Data: []byte("{}")},
+	}
+	cfg := testRouteConfig(fstest.MapFS{}, static)
+	var err error
+	if cfg.mimeTypes, err = parseMIMETypes(".map=application/json, .JS=application/javascript"); err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(t, cfg)
+
+	tests := map[string]string{
+		"/static/css/site.css":      "text/css; charset=utf-8",
+		"/static/js/APP.JS":         "application/javascript",
+		"/static/img/logo.svg":      "image/svg+xml",
+		"/static/fonts/inter.woff2": "font/woff2",
+		"/static/img/blob":          "image/png",
+		"/static/js/app.js.map":     "application/json",
+	}
+	for path, want := range tests {
+		resp, _ := getPage(t, app, path)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: status %d", path, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Type"); got != want {
+			t.Errorf("GET %s: Content-Type %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseMIMETypes(t *testing.T) {
+	for _, invalid := range []string{"map=application/json", ".map", ".map=not a type"} {
+		if _, err := parseMIMETypes(invalid); err == nil {
+			t.Errorf("parseMIMETypes(%q): want an error", invalid)
+		}
+	}
+}
+
+func TestValidateAssetsWarnsAboutMissingFiles(t *testing.T) {
+	templates := fstest.MapFS{
+		"index.html": {Data: []byte(`<link href="/static/css/site.css" rel="stylesheet">
+<script src="/static/js/app.js?v=2"></script><script src="/static/js/app.js"></script>
+<style>body { background: url('/static/img/bg.png') }</style>`)},
+	}
+	static := fstest.MapFS{"css/site.css": {Data: []byte("body {}")}}
+	want := []string{
+		"index.html references missing /static/img/bg.png",
+		"index.html references missing /static/js/app.js",
+	}
+
+	cached := newAssetCache(templates, decodeTemplate, false)
+	cached.preload()
+	if got := missingAssets(cached, newAssetCache(static, nil, true)); !slices.Equal(got, want) {
+		t.Errorf("missingAssets = %q, want %q", got, want)
+	}
+
+	for _, validate := range []bool{false, true} {
+		logs := captureLog(t)
+		cfg := testRouteConfig(templates, static)
+		cfg.validateAssets = validate
+		app := newTestApp(t, cfg)
+		for _, missing := range want {
+			if got := strings.Contains(logs.String(), "Warning: "+missing); got != validate {
+				t.Errorf("VALIDATE_ASSETS=%v: warned %q = %v", validate, missing, got)
+			}
+		}
+
+		// The page is served either way
+		if resp, _ := getPage(t, app, "/"); resp.StatusCode != http.StatusOK {
+			t.Errorf("VALIDATE_ASSETS=%v: status %d, want 200", validate, resp.StatusCode)
+		}
+	}
+}
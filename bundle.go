@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	styleBlockRe = regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
+	idAttrRe     = regexp.MustCompile(`\bid="([^"]+)"`)
+	hashHrefRe   = regexp.MustCompile(`\bhref="#([^"]*)"`)
+	pageTitleRe  = regexp.MustCompile(`\s*-\s*Knowledge Garden CLI\s*$`)
+)
+
+// tutorialBundle caches the combined tutorial document, rebuilding it when
+// one of the published tutorial templates changes.
+type tutorialBundle struct {
+	mu      sync.Mutex
+	version string
+	content []byte
+}
+
+func (b *tutorialBundle) get(templates *assetCache) ([]byte, error) {
+	version := bundleVersion(templates)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.content != nil && b.version == version {
+		return b.content, nil
+	}
+
+	content, err := buildTutorialBundle(templates)
+	if err != nil {
+		return nil, err
+	}
+	b.version, b.content = version, content
+	return content, nil
+}
+
+// bundleVersion fingerprints the published tutorial templates by their ETags.
+func bundleVersion(templates *assetCache) string {
+	tags := make([]string, 0, len(tutorialPages))
+	for _, page := range tutorialPages {
+		if page.Draft {
+			continue
+		}
+		file, err := templates.get(page.Template)
+		if err != nil {
+			tags = append(tags, "-")
+			continue
+		}
+		tags = append(tags, file.etag)
+	}
+	return strings.Join(tags, ",")
+}
+
+// buildTutorialBundle concatenates every published tutorial page into one
+// printable document with a table of contents. Element ids and in-page links
+// of each page are prefixed with its slug so anchors stay unique across pages.
+func buildTutorialBundle(templates *assetCache) ([]byte, error) {
+	var styles, toc, articles strings.Builder
+
+	for _, page := range tutorialPages {
+		if page.Draft {
+			continue
+		}
+		file, err := templates.get(page.Template)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", page.Template, err)
+		}
+		source := string(stripCSPNonce(file.content))
+		meta := extractTutorialMeta(source)
+		title := pageTitleRe.ReplaceAllString(meta.Title, "")
+
+		for _, style := range styleBlockRe.FindAllString(source, -1) {
+			styles.WriteString(style)
+			styles.WriteString("\n")
+		}
+
+		fmt.Fprintf(&toc, "<li><a href=\"#%s\">%s</a>", page.Slug, html.EscapeString(title))
+		if len(meta.Sections) > 0 {
+			toc.WriteString("<ol>")
+			for _, section := range meta.Sections {
+				fmt.Fprintf(&toc, "<li><a href=\"#%s-%s\">%s</a></li>", page.Slug, section.ID, html.EscapeString(section.Heading))
+			}
+			toc.WriteString("</ol>")
+		}
+		toc.WriteString("</li>\n")
+
+		body := pageContent(source)
+		body = idAttrRe.ReplaceAllString(body, `id="`+page.Slug+`-$1"`)
+		body = hashHrefRe.ReplaceAllString(body, `href="#`+page.Slug+`-$1"`)
+		fmt.Fprintf(&articles, "<article id=\"%s\" style=\"break-before: page\">\n%s\n</article>\n", page.Slug, body)
+	}
+
+	return []byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Complete Documentation - Knowledge Garden CLI</title>
+    <script nonce="` + cspNoncePlaceholder + `" src="https://cdn.tailwindcss.com"></script>
+` + styles.String() + `</head>
+<body class="bg-gradient-to-br from-purple-50 via-pink-50 to-orange-50">
+    <nav class="max-w-6xl mx-auto px-6 py-16">
+        <a href="/tutorial" class="text-gray-600 hover:text-purple-600 font-semibold">← Back to Docs</a>
+        <h1 class="text-4xl font-extrabold my-6 text-gray-900">📚 Knowledge Garden CLI Documentation</h1>
+        <ol class="list-decimal list-inside space-y-2 text-purple-700">
+` + toc.String() + `        </ol>
+    </nav>
+` + articles.String() + `</body>
+</html>
+`), nil
+}
+
+// pageContent returns the main content of a page: the body without its site
+// header and footer.
+func pageContent(source string) string {
+	body := source
+	if m := bodyRe.FindStringSubmatch(source); m != nil {
+		body = m[1]
+	}
+	if i := strings.Index(body, "</header>"); i >= 0 {
+		body = body[i+len("</header>"):]
+	}
+	if i := strings.LastIndex(body, "<footer"); i >= 0 {
+		body = body[:i]
+	}
+	return strings.TrimSpace(body)
+}
+
+// tutorialBundleHandler serves every tutorial page as one printable document.
+func tutorialBundleHandler(templates *assetCache) fiber.Handler {
+	combined := &tutorialBundle{}
+
+	return func(c *fiber.Ctx) error {
+		var content []byte
+		var err error
+		if featureOverride(c, overrideNoCache) {
+			content, err = buildTutorialBundle(templates)
+		} else {
+			content, err = combined.get(templates)
+		}
+		if err != nil {
+			return err
+		}
+		setDownload(c, "tutorials", "html")
+		return sendPage(c, content)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTutorialBundle(t *testing.T) {
+	templates := fstest.MapFS{}
+	for _, page := range tutorialPages {
+		templates[page.Template] = guide(page.Slug + " guide")
+	}
+	app := newTestApp(t, testRouteConfig(templates, fstest.MapFS{}))
+
+	resp, body := getPage(t, app, "/tutorial/all")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+
+	last := -1
+	for _, page := range tutorialPages {
+		article := strings.Index(body, `<article id="`+page.Slug+`"`)
+		if article < 0 {
+			t.Errorf("no article for %s", page.Slug)
+			continue
+		}
+		if article < last {
+			t.Errorf("article %s is out of tutorialPages order", page.Slug)
+		}
+		last = article
+
+		// Each guide's sections are prefixed with its slug, in the table of
+		// contents and on the sections themselves
+		for _, want := range []string{
+			`<a href="#` + page.Slug + `">` + page.Slug + ` guide</a>`,
+			`<a href="#` + page.Slug + `-install">📦 Install</a>`,
+			`<section id="` + page.Slug + `-install">`,
+			`<section id="` + page.Slug + `-usage">`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("bundle does not contain %s", want)
+			}
+		}
+	}
+	if strings.Contains(body, `<section id="install">`) {
+		t.Error("bundle kept an unprefixed section id")
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// changelogHeadingRe matches release headings such as "## [1.2.0] - 2026-01-31",
+// "## v1.2.0 (2026-01-31)" or "## Unreleased".
+var changelogHeadingRe = regexp.MustCompile(`^##\s+\[?([^\]\s(]+)\]?(?:\s*[-–(]\s*([^)]+?)\)?)?\s*$`)
+
+// changelogSection is a group of changes within a release, e.g. "Added".
+type changelogSection struct {
+	Name  string   `json:"name"`
+	Items []string `json:"items"`
+}
+
+// changelogEntry is one release in the changelog.
+type changelogEntry struct {
+	Version  string             `json:"version"`
+	Date     string             `json:"date,omitempty"`
+	Sections []changelogSection `json:"sections"`
+}
+
+// parseChangelog parses a Keep a Changelog style Markdown file: "## " headings
+// start a release, "### " headings start a section and "-"/"*" lines are
+// items. Items outside a "### " heading go into a "Changes" section.
+func parseChangelog(content []byte) []changelogEntry {
+	entries := []changelogEntry{}
+	var entry *changelogEntry
+	var section *changelogSection
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "## "):
+			m := changelogHeadingRe.FindStringSubmatch(line)
+			if m == nil {
+				m = []string{line, strings.TrimSpace(strings.TrimPrefix(line, "## ")), ""}
+			}
+			entries = append(entries, changelogEntry{Version: m[1], Date: strings.TrimSpace(m[2]), Sections: []changelogSection{}})
+			entry, section = &entries[len(entries)-1], nil
+		case entry == nil:
+			// Title and preamble before the first release
+		case strings.HasPrefix(line, "### "):
+			entry.Sections = append(entry.Sections, changelogSection{Name: strings.TrimSpace(line[4:]), Items: []string{}})
+			section = &entry.Sections[len(entry.Sections)-1]
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			if section == nil {
+				entry.Sections = append(entry.Sections, changelogSection{Name: "Changes", Items: []string{}})
+				section = &entry.Sections[len(entry.Sections)-1]
+			}
+			section.Items = append(section.Items, strings.TrimSpace(line[2:]))
+		}
+	}
+	return entries
+}
+
+// changelogStore holds the parsed changelog, loaded at startup and on reload.
+type changelogStore struct {
+	fsys fs.FS
+	name string
+
+	mu      sync.RWMutex
+	entries []changelogEntry
+	found   bool
+}
+
+func (s *changelogStore) load() error {
+	content, err := fs.ReadFile(s.fsys, s.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.mu.Lock()
+		s.entries, s.found = nil, false
+		s.mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read changelog: %w", err)
+	}
+
+	entries := parseChangelog(content)
+	s.mu.Lock()
+	s.entries, s.found = entries, true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *changelogStore) get() ([]changelogEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.entries, s.found
+}
+
+func renderChangelog(entries []changelogEntry) []byte {
+	var body strings.Builder
+	for _, entry := range entries {
+		body.WriteString("        <section class=\"bg-white rounded-3xl p-8 shadow-lg border-2 border-purple-100 mb-6\">\n")
+		fmt.Fprintf(&body, "            <h2 class=\"text-2xl font-bold text-gray-900\">%s", html.EscapeString(entry.Version))
+		if entry.Date != "" {
+			fmt.Fprintf(&body, " <span class=\"text-base font-semibold text-gray-500\">%s</span>", html.EscapeString(entry.Date))
+		}
+		body.WriteString("</h2>\n")
+		for _, section := range entry.Sections {
+			fmt.Fprintf(&body, "            <h3 class=\"text-lg font-bold mt-4 mb-2 text-purple-700\">%s</h3>\n            <ul class=\"list-disc list-inside text-gray-700 space-y-1\">\n", html.EscapeString(section.Name))
+			for _, item := range section.Items {
+				fmt.Fprintf(&body, "                <li>%s</li>\n", html.EscapeString(item))
+			}
+			body.WriteString("            </ul>\n")
+		}
+		body.WriteString("        </section>\n")
+	}
+
+	return []byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Changelog - Knowledge Garden CLI</title>
+    <script nonce="` + cspNoncePlaceholder + `" src="https://cdn.tailwindcss.com"></script>
+</head>
+<body class="bg-gradient-to-br from-purple-50 via-pink-50 to-orange-50">
+    <main class="max-w-4xl mx-auto px-6 py-16">
+        <a href="/" class="text-gray-600 hover:text-purple-600 font-semibold">← Back to Home</a>
+        <h1 class="text-4xl font-extrabold my-6 text-gray-900">📝 Changelog</h1>
+` + body.String() + `    </main>
+</body>
+</html>
+`)
+}
+
+// setupChangelog serves the changelog file name in fsys on /changelog (HTML)
+// and /api/changelog (JSON). Both return 404 while no changelog file exists.
+func setupChangelog(app *fiber.App, fsys fs.FS, name string, reloads *reloader) {
+	store := &changelogStore{fsys: fsys, name: name}
+	if err := store.load(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	reloads.add("changelog", store.load)
+
+	app.Get("/changelog", func(c *fiber.Ctx) error {
+		entries, found := store.get()
+		if !found {
+			return c.Next()
+		}
+		return sendPage(c, renderChangelog(entries))
+	})
+
+	app.Get("/api/changelog", func(c *fiber.Ctx) error {
+		entries, found := store.get()
+		if !found {
+			return sendError(c, fiber.StatusNotFound, "Changelog not found")
+		}
+		return c.JSON(fiber.Map{
+			"version":  version,
+			"releases": entries,
+		})
+	})
+}
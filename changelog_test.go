@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const changelogFixture = `# Changelog
+
+All notable changes to this project.
+
+## [Unreleased]
+- Work in progress
+
+## [1.2.0] - 2026-01-31
+### Added
+- /changelog page
+* JSON API
+
+### Fixed
+- Typo in the README
+
+## v1.1.0 (2025-12-01)
+- First public release
+`
+
+func TestParseChangelog(t *testing.T) {
+	want := []changelogEntry{
+		{Version: "Unreleased", Sections: []changelogSection{{Name: "Changes", Items: []string{"Work in progress"}}}},
+		{Version: "1.2.0", Date: "2026-01-31", Sections: []changelogSection{
+			{Name: "Added", Items: []string{"/changelog page", "JSON API"}},
+			{Name: "Fixed", Items: []string{"Typo in the README"}},
+		}},
+		{Version: "v1.1.0", Date: "2025-12-01", Sections: []changelogSection{{Name: "Changes", Items: []string{"First public release"}}}},
+	}
+	if got := parseChangelog([]byte(changelogFixture)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseChangelog =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := parseChangelog([]byte("# Changelog\n\nNothing yet.\n")); len(got) != 0 {
+		t.Errorf("changelog without releases: %+v, want none", got)
+	}
+}
+
+func TestChangelogRoutes(t *testing.T) {
+	cfg := testRouteConfig(fstest.MapFS{}, fstest.MapFS{})
+	cfg.changelog = fstest.MapFS{"CHANGELOG.md": {Data: []byte(changelogFixture)}}
+	app := newTestApp(t, cfg)
+
+	resp, body := getJSON(t, app, "/api/changelog")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("/api/changelog: status %d", resp.StatusCode)
+	}
+	var api struct {
+		Releases []changelogEntry `json:"releases"`
+	}
+	if err := json.Unmarshal([]byte(body), &api); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	if len(api.Releases) != 3 || api.Releases[1].Version != "1.2.0" {
+		t.Errorf("/api/changelog releases %+v", api.Releases)
+	}
+
+	resp, body = getPage(t, app, "/changelog")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "Typo in the README") {
+		t.Errorf("/changelog: status %d, body %q", resp.StatusCode, body)
+	}
+
+	// Without a changelog file both routes are 404
+	app = newTestApp(t, testRouteConfig(fstest.MapFS{}, fstest.MapFS{}))
+	for _, path := range []string{"/changelog", "/api/changelog"} {
+		if resp, _ := getJSON(t, app, path); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s without a changelog: status %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// cliReferenceTemplate documents the CLI commands served by /api/cli/commands.
+const cliReferenceTemplate = "tutorial-cli-reference.html"
+
+var (
+	detailsRe    = regexp.MustCompile(`(?is)<details\b.*?</details>`)
+	usageRe      = regexp.MustCompile(`(?is)<code class="kg-code-inline">(.*?)</code>\s*<span[^>]*>(.*?)</span>`)
+	tableRowRe   = regexp.MustCompile(`(?is)<tr>(.*?)</tr>`)
+	tableCellRe  = regexp.MustCompile(`(?is)<td[^>]*>(.*?)</td>`)
+	usageArgsRe  = regexp.MustCompile(`\s*(<[^>]*>|\[[^\]]*\]).*$`)
+	noneValuesRe = regexp.MustCompile(`^-?$`)
+)
+
+// cliFlag is a flag documented for a CLI command.
+type cliFlag struct {
+	Name        string `json:"name"`
+	Short       string `json:"short,omitempty"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+}
+
+// cliCommand is a CLI command documented in the CLI reference.
+type cliCommand struct {
+	Command     string    `json:"command"`
+	Usage       string    `json:"usage"`
+	Description string    `json:"description"`
+	Section     string    `json:"section,omitempty"`
+	Flags       []cliFlag `json:"flags"`
+}
+
+// parseCLICommands extracts the commands and flags documented in the CLI
+// reference: each command is a <details> block whose summary holds the usage
+// in a kg-code-inline <code> followed by a description, with flags listed in
+// table rows whose first cell is a --flag.
+func parseCLICommands(content string) ([]cliCommand, error) {
+	sections := sectionRe.FindAllStringSubmatchIndex(content, -1)
+	sectionAt := func(pos int) string {
+		id := ""
+		for _, loc := range sections {
+			if loc[0] > pos {
+				break
+			}
+			id = content[loc[2]:loc[3]]
+		}
+		return id
+	}
+
+	commands := []cliCommand{}
+	for _, loc := range detailsRe.FindAllStringIndex(content, -1) {
+		block := content[loc[0]:loc[1]]
+		m := usageRe.FindStringSubmatch(block)
+		if m == nil {
+			continue
+		}
+
+		usage := textContent(m[1])
+		command := cliCommand{
+			Command:     usageArgsRe.ReplaceAllString(usage, ""),
+			Usage:       usage,
+			Description: textContent(m[2]),
+			Section:     sectionAt(loc[0]),
+			Flags:       []cliFlag{},
+		}
+
+		for _, row := range tableRowRe.FindAllStringSubmatch(block, -1) {
+			var cells []string
+			for _, cell := range tableCellRe.FindAllStringSubmatch(row[1], -1) {
+				cells = append(cells, textContent(cell[1]))
+			}
+			// Flag tables are Flag | Short | Description [| Default]
+			if len(cells) < 3 || !strings.HasPrefix(cells[0], "--") {
+				continue
+			}
+			flag := cliFlag{Name: cells[0], Description: cells[2]}
+			if !noneValuesRe.MatchString(cells[1]) {
+				flag.Short = cells[1]
+			}
+			if len(cells) > 3 && !noneValuesRe.MatchString(cells[3]) {
+				flag.Default = cells[3]
+			}
+			command.Flags = append(command.Flags, flag)
+		}
+
+		commands = append(commands, command)
+	}
+
+	if len(commands) == 0 {
+		return commands, errors.New("no commands found")
+	}
+	return commands, nil
+}
+
+// cliCommandsCache holds the commands parsed from the CLI reference, parsed
+// again only when the template changes, e.g. after a reload.
+type cliCommandsCache struct {
+	mu       sync.Mutex
+	parsed   bool
+	etag     string
+	commands []cliCommand
+	warning  string
+}
+
+// get returns the parsed commands. On failure it logs a warning once and
+// returns an empty list with the warning, so the endpoint keeps working.
+func (p *cliCommandsCache) get(templates *assetCache) ([]cliCommand, string) {
+	file, err := templates.get(cliReferenceTemplate)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.parsed && p.etag == file.etag {
+		return p.commands, p.warning
+	}
+
+	var commands []cliCommand
+	if err == nil {
+		commands, err = parseCLICommands(string(file.content))
+	}
+	p.parsed, p.etag, p.commands, p.warning = true, file.etag, commands, ""
+	if err != nil {
+		p.commands = []cliCommand{}
+		p.warning = "CLI reference could not be parsed: " + err.Error()
+		log.Printf("Warning: %s", p.warning)
+	}
+	return p.commands, p.warning
+}
+
+// cliCommandsHandler serves the CLI commands parsed from the CLI reference.
+func cliCommandsHandler(templates *assetCache) fiber.Handler {
+	parsed := &cliCommandsCache{}
+	parsed.get(templates)
+
+	return func(c *fiber.Ctx) error {
+		commands, warning := parsed.get(templates)
+		body := fiber.Map{
+			"commands": commands,
+			"count":    len(commands),
+		}
+		if warning != "" {
+			body["warning"] = warning
+		}
+		return c.JSON(body)
+	}
+}
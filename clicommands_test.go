@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const cliReferenceCommands = `<html><head><title>CLI Reference</title></head><body>
+<section id="notes">
+<details>
+  <summary><code class="kg-code-inline">kg add &lt;title&gt; [--tags]</code> <span>Create a note</span></summary>
+  <table>
+    <tr><th>Flag</th><th>Short</th><th>Description</th><th>Default</th></tr>
+    <tr><td>--tags</td><td>-t</td><td>Comma-separated tags</td><td>-</td></tr>
+    <tr><td>--editor</td><td>-</td><td>Open in $EDITOR</td><td>false</td></tr>
+  </table>
+</details>
+</section>
+<section id="search">
+<details>
+  <summary><code class="kg-code-inline">kg search</code> <span>Search notes</span></summary>
+</details>
+</section>
+</body></html>`
+
+func TestParseCLICommands(t *testing.T) {
+	commands, err := parseCLICommands(cliReferenceCommands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cliCommand{
+		{
+			Command:     "kg add",
+			Usage:       "kg add <title> [--tags]",
+			Description: "Create a note",
+			Section:     "notes",
+			Flags: []cliFlag{
+				{Name: "--tags", Short: "-t", Description: "Comma-separated tags"},
+				{Name: "--editor", Description: "Open in $EDITOR", Default: "false"},
+			},
+		},
+		{Command: "kg search", Usage: "kg search", Description: "Search notes", Section: "search", Flags: []cliFlag{}},
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("parseCLICommands =\n%+v\nwant\n%+v", commands, want)
+	}
+
+	if _, err := parseCLICommands("<html><body>no commands</body></html>"); err == nil {
+		t.Error("parseCLICommands without commands: want an error")
+	}
+}
+
+func TestCLICommandsHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		count   int
+		warning bool
+	}{
+		{"parsed", cliReferenceCommands, 2, false},
+		{"unparsable", "<html><body>no commands</body></html>", 0, true},
+	}
+	for _, tt := range tests {
+		app := newTestApp(t, testRouteConfig(fstest.MapFS{
+			cliReferenceTemplate: {Data: []byte(tt.content)},
+		}, fstest.MapFS{}))
+
+		resp, body := getJSON(t, app, "/api/cli/commands")
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: status %d, want 200", tt.name, resp.StatusCode)
+		}
+		var list struct {
+			Commands []cliCommand `json:"commands"`
+			Count    int          `json:"count"`
+			Warning  string       `json:"warning"`
+		}
+		if err := json.Unmarshal([]byte(body), &list); err != nil {
+			t.Fatalf("%s: decode %q: %v", tt.name, body, err)
+		}
+		if list.Count != tt.count || len(list.Commands) != tt.count {
+			t.Errorf("%s: count %d with %d commands, want %d", tt.name, list.Count, len(list.Commands), tt.count)
+		}
+		if got := list.Warning != ""; got != tt.warning {
+			t.Errorf("%s: warning %q, want one = %v", tt.name, list.Warning, tt.warning)
+		}
+	}
+}
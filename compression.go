@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// compressMode is a per-route override of the global COMPRESS setting.
+type compressMode int
+
+const (
+	compressDefault compressMode = iota // follow COMPRESS
+	compressForce                       // compress even when COMPRESS=false
+	compressSkip                        // never compress
+)
+
+var compressModes = map[string]compressMode{
+	"default": compressDefault,
+	"force":   compressForce,
+	"skip":    compressSkip,
+}
+
+// parseCompressRoutes parses "/prefix=mode,..." into a map of path prefix to
+// compression mode, where mode is default, force or skip.
+func parseCompressRoutes(value string) (map[string]compressMode, error) {
+	routes := make(map[string]compressMode)
+	for _, def := range splitList(value) {
+		prefix, name, ok := strings.Cut(def, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("%q must be /prefix=default|force|skip", def)
+		}
+		mode, ok := compressModes[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("%q has an unknown mode, want default, force or skip", def)
+		}
+		routes[strings.TrimSpace(prefix)] = mode
+	}
+	return routes, nil
+}
+
+// compressModeFor returns the mode of the longest prefix matching path.
+func compressModeFor(path string, routes map[string]compressMode) compressMode {
+	mode, matchLen := compressDefault, -1
+	for prefix, m := range routes {
+		if hasPathPrefix(path, prefix) && len(prefix) > matchLen {
+			mode, matchLen = m, len(prefix)
+		}
+	}
+	return mode
+}
+
+// skipCompression is the compression middleware's Next predicate. Per-route
+// modes take precedence over enabled; the development nocompress override
+// takes precedence over both. Responses that are compressed still have to
+// pass the compressor's own size and content type checks.
+func skipCompression(enabled bool, routes map[string]compressMode) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		if featureOverride(c, overrideNoCompress) {
+			return true
+		}
+		switch compressModeFor(c.Path(), routes) {
+		case compressForce:
+			return false
+		case compressSkip:
+			return true
+		}
+		return !enabled
+	}
+}
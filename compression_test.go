@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPerRouteCompression(t *testing.T) {
+	tests := []struct {
+		compress string
+		routes   string
+		path     string
+		want     bool
+	}{
+		{"true", "", "/docs", true},
+		{"false", "", "/docs", false},
+		{"false", "/docs=force", "/docs/tui", true},
+		{"false", "/docs=force", "/docsx", false},
+		{"false", "/docs=force,/docs/raw=skip", "/docs/raw/file", false},
+		{"true", "/static=skip", "/static/app.js", false},
+		{"true", "/static=skip", "/tutorial", true},
+		{"true", "/static=skip,/static/css=default", "/static/css/site.css", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("COMPRESS", tt.compress)
+		t.Setenv("COMPRESS_ROUTES", tt.routes)
+		app := setupFiber()
+		app.Get("/*", func(c *fiber.Ctx) error {
+			return c.SendString(strings.Repeat("compressible text ", 200))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+		resp, _ := send(t, app, req)
+		if got := resp.Header.Get(fiber.HeaderContentEncoding) == "gzip"; got != tt.want {
+			t.Errorf("COMPRESS=%s COMPRESS_ROUTES=%q GET %s: compressed = %v, want %v", tt.compress, tt.routes, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseCompressRoutes(t *testing.T) {
+	for _, invalid := range []string{"docs=force", "/docs", "/docs=gzip"} {
+		if _, err := parseCompressRoutes(invalid); err == nil {
+			t.Errorf("parseCompressRoutes(%q): want an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// routeConfig is everything setupRoutes serves from. main reads it from the
+// environment with loadRouteConfig; tests build it directly around in-memory
+// file systems.
+type routeConfig struct {
+	templates fs.FS
+	static    fs.FS
+	// changelog is the directory holding changelogFile.
+	changelog     fs.FS
+	changelogFile string
+
+	serveStaleOnError bool
+	previewToken      string
+	validateAssets    bool
+	mimeTypes         map[string]string
+
+	ready         readiness
+	statusEnabled bool
+	statusToken   string
+	statsEnabled  bool
+
+	debugVarsEnabled bool
+	debugVarsToken   string
+
+	liveReload bool
+	adminToken string
+}
+
+// loadRouteConfig reads the route configuration from the environment. Errors
+// name the offending variable.
+func loadRouteConfig() (routeConfig, error) {
+	// Templates and static files are embedded, unless overridden from disk
+	templates, err := assetFS("templates", getEnv("TEMPLATES_DIR", ""))
+	if err != nil {
+		return routeConfig{}, fmt.Errorf("TEMPLATES_DIR: %w", err)
+	}
+	static, err := assetFS("static", getEnv("STATIC_DIR", ""))
+	if err != nil {
+		return routeConfig{}, fmt.Errorf("STATIC_DIR: %w", err)
+	}
+	mimeTypes, err := parseMIMETypes(getEnv("MIME_TYPES", ""))
+	if err != nil {
+		return routeConfig{}, fmt.Errorf("MIME_TYPES: %w", err)
+	}
+	upstream, err := parseUpstreamURL(getEnv("API_BASE_URL", ""))
+	if err != nil {
+		return routeConfig{}, fmt.Errorf("API_BASE_URL: %w", err)
+	}
+	changelog := getEnv("CHANGELOG_FILE", "CHANGELOG.md")
+	// expvar exposes memory and runtime internals, so it is never served open
+	debugVarsEnabled := getEnvBool("ENABLE_DEBUG_VARS", false)
+	debugVarsToken := getEnv("DEBUG_VARS_TOKEN", "")
+	if debugVarsEnabled && debugVarsToken == "" {
+		return routeConfig{}, errors.New("DEBUG_VARS_TOKEN: required when ENABLE_DEBUG_VARS=true")
+	}
+
+	return routeConfig{
+		templates:     templates,
+		static:        static,
+		changelog:     os.DirFS(filepath.Dir(changelog)),
+		changelogFile: filepath.Base(changelog),
+
+		serveStaleOnError: getEnvBool("SERVE_STALE_ON_ERROR", true),
+		previewToken:      getEnv("PREVIEW_TOKEN", ""),
+		validateAssets:    getEnvBool("VALIDATE_ASSETS", false),
+		mimeTypes:         mimeTypes,
+
+		ready: readiness{
+			upstream: upstream,
+			check:    httpUpstreamChecker(http.DefaultClient),
+			timeout:  getEnvDuration("READY_TIMEOUT", 2*time.Second),
+		},
+		statusEnabled: getEnvBool("ENABLE_STATUS", false),
+		statusToken:   getEnv("STATUS_TOKEN", ""),
+		statsEnabled:  getEnvBool("ENABLE_STATS", false),
+
+		debugVarsEnabled: debugVarsEnabled,
+		debugVarsToken:   debugVarsToken,
+
+		liveReload: isDevelopment() && getEnvBool("LIVE_RELOAD", false),
+		adminToken: getEnv("ADMIN_TOKEN", ""),
+	}, nil
+}
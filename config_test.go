@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadRouteConfigRequiresDebugVarsToken(t *testing.T) {
+	tests := []struct {
+		enabled string
+		token   string
+		err     string
+	}{
+		{"false", "", ""},
+		{"true", "secret", ""},
+		{"true", "", "DEBUG_VARS_TOKEN: required when ENABLE_DEBUG_VARS=true"},
+	}
+	for _, tt := range tests {
+		t.Setenv("ENABLE_DEBUG_VARS", tt.enabled)
+		t.Setenv("DEBUG_VARS_TOKEN", tt.token)
+
+		cfg, err := loadRouteConfig()
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("ENABLE_DEBUG_VARS=%s DEBUG_VARS_TOKEN=%q: %v", tt.enabled, tt.token, err)
+			} else if cfg.debugVarsToken != tt.token {
+				t.Errorf("debugVarsToken %q, want %q", cfg.debugVarsToken, tt.token)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ENABLE_DEBUG_VARS=%s DEBUG_VARS_TOKEN=%q: error %v, want %q", tt.enabled, tt.token, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	headerNonceRe = regexp.MustCompile(`script-src 'nonce-([^']+)'`)
+	bodyNonceRe   = regexp.MustCompile(`<script nonce="([^"]+)"`)
+)
+
+func TestCSPNonceMatchesBody(t *testing.T) {
+	templates := fstest.MapFS{
+		"index.html": {Data: []byte(`<html><head><script nonce="` + cspNoncePlaceholder + `" src="/static/app.js"></script></head></html>`)},
+	}
+	app := fiber.New()
+	app.Use(cspNonce)
+	registerTemplateRoutes(app, templates, map[string]string{"/": "index.html"})
+
+	var nonces []string
+	for range 2 {
+		resp, body := getPage(t, app, "/")
+		header := headerNonceRe.FindStringSubmatch(resp.Header.Get(fiber.HeaderContentSecurityPolicy))
+		inBody := bodyNonceRe.FindStringSubmatch(body)
+		if header == nil || inBody == nil {
+			t.Fatalf("nonce missing: header %q, body %q", resp.Header.Get(fiber.HeaderContentSecurityPolicy), body)
+		}
+		if header[1] != inBody[1] {
+			t.Errorf("header nonce %q, body nonce %q, want them equal", header[1], inBody[1])
+		}
+		nonces = append(nonces, header[1])
+	}
+	if nonces[0] == nonces[1] {
+		t.Errorf("two responses share the nonce %q", nonces[0])
+	}
+}
+
+func TestCSPNonceStrippedWhenDisabled(t *testing.T) {
+	templates := fstest.MapFS{
+		"index.html": {Data: []byte(`<script nonce="` + cspNoncePlaceholder + `" src="/static/app.js"></script>`)},
+	}
+	app := fiber.New()
+	registerTemplateRoutes(app, templates, map[string]string{"/": "index.html"})
+
+	resp, body := getPage(t, app, "/")
+	if got := resp.Header.Get(fiber.HeaderContentSecurityPolicy); got != "" {
+		t.Errorf("Content-Security-Policy %q, want none", got)
+	}
+	if strings.Contains(body, "nonce") {
+		t.Errorf("body %q still has a nonce attribute", body)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"expvar"
+	"runtime"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	expvarmw "github.com/gofiber/fiber/v2/middleware/expvar"
+)
+
+var publishRuntimeOnce sync.Once
+
+// publishRuntimeStats registers a "runtime" expvar with the goroutine count,
+// heap usage and GC pause figures, computed fresh on every read.
+func publishRuntimeStats() {
+	publishRuntimeOnce.Do(func() {
+		expvar.Publish("runtime", expvar.Func(func() any {
+			var m runtime.MemStats
+			runtime.ReadMemStats(&m)
+			return map[string]any{
+				"goroutines":        runtime.NumGoroutine(),
+				"heap_alloc_bytes":  m.HeapAlloc,
+				"heap_inuse_bytes":  m.HeapInuse,
+				"heap_objects":      m.HeapObjects,
+				"num_gc":            m.NumGC,
+				"gc_pause_last_ns":  m.PauseNs[(m.NumGC+255)%256],
+				"gc_pause_total_ns": m.PauseTotalNs,
+			}
+		}))
+	})
+}
+
+// setupDebugVars serves expvar (including runtime stats) on /debug/vars,
+// protected by token when set.
+func setupDebugVars(app *fiber.App, token string) {
+	publishRuntimeStats()
+	app.Get("/debug/vars", requireToken(token), expvarmw.New())
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// debugVarsApp returns an app with /debug/vars enabled or not, behind token.
+func debugVarsApp(t *testing.T, enabled bool, token string) *fiber.App {
+	t.Helper()
+	cfg := testRouteConfig(fstest.MapFS{"404.html": page("Not Found", "custom not found")}, fstest.MapFS{})
+	cfg.debugVarsEnabled = enabled
+	cfg.debugVarsToken = token
+	return newTestApp(t, cfg)
+}
+
+func TestDebugVarsRuntimeStats(t *testing.T) {
+	app := debugVarsApp(t, true, "secret")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer secret")
+	resp, body := send(t, app, req)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+
+	var vars struct {
+		Runtime map[string]any `json:"runtime"`
+	}
+	if err := json.Unmarshal([]byte(body), &vars); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	for _, field := range []string{
+		"goroutines",
+		"heap_alloc_bytes",
+		"heap_inuse_bytes",
+		"heap_objects",
+		"num_gc",
+		"gc_pause_last_ns",
+		"gc_pause_total_ns",
+	} {
+		value, ok := vars.Runtime[field]
+		if !ok {
+			t.Errorf("runtime.%s missing from %v", field, vars.Runtime)
+			continue
+		}
+		if _, ok := value.(float64); !ok {
+			t.Errorf("runtime.%s = %v (%T), want a number", field, value, value)
+		}
+	}
+	if goroutines, _ := vars.Runtime["goroutines"].(float64); goroutines < 1 {
+		t.Errorf("runtime.goroutines = %v, want at least 1", goroutines)
+	}
+}
+
+func TestDebugVarsRequiresToken(t *testing.T) {
+	app := debugVarsApp(t, true, "secret")
+
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+		if auth != "" {
+			req.Header.Set(fiber.HeaderAuthorization, auth)
+		}
+		resp, body := send(t, app, req)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", auth, resp.StatusCode)
+		}
+		if !json.Valid([]byte(body)) {
+			t.Errorf("Authorization %q: body %q, want a JSON error", auth, body)
+		}
+	}
+	if resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, "/debug/vars?token=wrong", nil)); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("?token=wrong: status %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestDebugVarsDisabled(t *testing.T) {
+	app := debugVarsApp(t, false, "secret")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars?token=secret", nil)
+	if resp, _ := send(t, app, req); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status %d, want 404 while ENABLE_DEBUG_VARS=false", resp.StatusCode)
+	}
+	for _, route := range app.GetRoutes() {
+		if route.Path == "/debug/vars" {
+			t.Errorf("%s /debug/vars registered while disabled", route.Method)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// deprecation is when a route was deprecated and when it will be removed.
+type deprecation struct {
+	at     time.Time
+	sunset time.Time
+}
+
+// parseDeprecatedRoutes parses "route=YYYY-MM-DD[@YYYY-MM-DD],..." into a map
+// of route path to its sunset date and, after the @, its deprecation date.
+// Routes without a deprecation date count as deprecated from now. Routes are
+// matched on their registered path, so parameterised routes are written as
+// registered, e.g. /api/tutorials/:slug.
+func parseDeprecatedRoutes(value string, now time.Time) (map[string]deprecation, error) {
+	routes := make(map[string]deprecation)
+	for _, def := range splitList(value) {
+		route, dates, ok := strings.Cut(def, "=")
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("%q must be /route=YYYY-MM-DD[@YYYY-MM-DD]", def)
+		}
+		sunsetDate, deprecatedDate, hasDeprecated := strings.Cut(dates, "@")
+		sunset, err := time.Parse(time.DateOnly, strings.TrimSpace(sunsetDate))
+		if err != nil {
+			return nil, fmt.Errorf("%q has an invalid sunset date: %w", def, err)
+		}
+		d := deprecation{at: now.Truncate(time.Second), sunset: sunset}
+		if hasDeprecated {
+			if d.at, err = time.Parse(time.DateOnly, strings.TrimSpace(deprecatedDate)); err != nil {
+				return nil, fmt.Errorf("%q has an invalid deprecation date: %w", def, err)
+			}
+			if d.at.After(sunset) {
+				return nil, fmt.Errorf("%q is deprecated after its sunset", def)
+			}
+		}
+		routes[strings.TrimSpace(route)] = d
+	}
+	return routes, nil
+}
+
+// deprecationHeaders marks responses from deprecated routes with a
+// Deprecation header holding the deprecation date (RFC 9745) and the route's
+// Sunset date (RFC 8594). The routes keep working. Requests answered by the
+// catch-all 404 handler matched no route: Fiber reports the catch-all's path
+// as "/", so they would otherwise be taken for a deprecated "/".
+func deprecationHeaders(routes map[string]deprecation) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if caughtAll, _ := c.Locals(catchAllKey).(bool); caughtAll {
+			return err
+		}
+
+		if d, ok := routes[c.Route().Path]; ok {
+			c.Set("Deprecation", "@"+strconv.FormatInt(d.at.Unix(), 10))
+			c.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+		}
+		return err
+	}
+}
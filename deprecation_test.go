@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseDeprecatedRoutes(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  map[string]deprecation
+		ok    bool
+	}{
+		{"", map[string]deprecation{}, true},
+		{"/api/old=2027-01-31", map[string]deprecation{
+			"/api/old": {at: now, sunset: time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)},
+		}, true},
+		{"/api/old=2027-01-31@2026-01-01, /legacy=2027-06-30", map[string]deprecation{
+			"/api/old": {at: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), sunset: time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)},
+			"/legacy":  {at: now, sunset: time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)},
+		}, true},
+		{"api/old=2027-01-31", nil, false},
+		{"/api/old", nil, false},
+		{"/api/old=31-01-2027", nil, false},
+		{"/api/old=2027-01-31@soon", nil, false},
+		{"/api/old=2027-01-31@2027-02-01", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := parseDeprecatedRoutes(tt.value, now)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseDeprecatedRoutes(%q): error %v, want ok=%v", tt.value, err, tt.ok)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseDeprecatedRoutes(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+		for route, want := range tt.want {
+			if d := got[route]; !d.at.Equal(want.at) || !d.sunset.Equal(want.sunset) {
+				t.Errorf("parseDeprecatedRoutes(%q)[%s] = %v, want %v", tt.value, route, d, want)
+			}
+		}
+	}
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	routes, err := parseDeprecatedRoutes("/api/tutorials/:slug=2027-01-31@2026-01-01", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	app.Use(deprecationHeaders(routes))
+	app.Get("/api/tutorials/:slug", func(c *fiber.Ctx) error { return c.SendString("old") })
+	app.Get("/api/current", func(c *fiber.Ctx) error { return c.SendString("new") })
+
+	resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, "/api/tutorials/tui", nil))
+	if got, want := resp.Header.Get("Deprecation"), "@1767225600"; got != want {
+		t.Errorf("Deprecation %q, want %q", got, want)
+	}
+	if got, want := resp.Header.Get("Sunset"), "Sun, 31 Jan 2027 00:00:00 GMT"; got != want {
+		t.Errorf("Sunset %q, want %q", got, want)
+	}
+
+	resp, _ = send(t, app, httptest.NewRequest(http.MethodGet, "/api/current", nil))
+	if got := resp.Header.Get("Deprecation") + resp.Header.Get("Sunset"); got != "" {
+		t.Errorf("undeprecated route sent Deprecation/Sunset %q", got)
+	}
+}
+
+func TestDeprecatedRootDoesNotMarkUnknownPaths(t *testing.T) {
+	t.Setenv("DEPRECATED_ROUTES", "/=2027-01-31")
+	app := setupFiber()
+	setupRoutes(app, testRouteConfig(fstest.MapFS{
+		"index.html": page("Home", "home"),
+		"404.html":   page("Not Found", "custom not found"),
+	}, fstest.MapFS{}))
+
+	tests := []struct {
+		path       string
+		status     int
+		deprecated bool
+	}{
+		{"/", http.StatusOK, true},
+		{"/no-such-page", http.StatusNotFound, false},
+		{"/tutorial/nope", http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		resp, _ := getPage(t, app, tt.path)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if got := resp.Header.Get("Deprecation") != ""; got != tt.deprecated {
+			t.Errorf("GET %s: Deprecation sent = %v, want %v", tt.path, got, tt.deprecated)
+		}
+		if got := resp.Header.Get("Sunset") != ""; got != tt.deprecated {
+			t.Errorf("GET %s: Sunset sent = %v, want %v", tt.path, got, tt.deprecated)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// problemDetails switches JSON error bodies from {"error": "..."} to RFC 7807
+// application/problem+json. Set from PROBLEM_DETAILS in setupFiber.
+var problemDetails bool
+
+// errorPage is the HTML body sent to browsers for errors that have no
+// dedicated template.
+const errorPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%[1]d %[2]s - Knowledge Garden CLI</title>
+</head>
+<body style="font-family: system-ui, sans-serif; max-width: 40rem; margin: 4rem auto; padding: 0 1rem;">
+    <h1>%[1]d %[2]s</h1>
+    <p>%[3]s</p>
+    <p><a href="/">Back to Home</a></p>
+</body>
+</html>
+`
+
+// jsonRoutes are the path prefixes of the endpoints that answer in JSON.
+var jsonRoutes = []string{"/api", "/health", "/ready", "/stats", "/debug/vars", "/livereload"}
+
+// isJSONRoute reports whether path belongs to an endpoint that answers in
+// JSON. It goes by path alone, so errors raised by middleware before the
+// route runs (rate limiting, query validation) match the endpoint too.
+func isJSONRoute(path string) bool {
+	for _, prefix := range jsonRoutes {
+		if hasPathPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// preferredErrorFormat returns "html" or "json" for error responses. A
+// ?format=html or ?format=json query overrides the Accept header. JSON routes
+// answer errors in JSON unless the client asks for HTML, so curl and API
+// clients sending */* or no Accept header get JSON. Everything else answers
+// in HTML unless the client prefers JSON over HTML.
+func preferredErrorFormat(c *fiber.Ctx) string {
+	switch format := c.Query("format"); format {
+	case "html", "json":
+		return format
+	}
+	if isJSONRoute(c.Path()) {
+		if c.Accepts(fiber.MIMEApplicationJSON, "application/problem+json", fiber.MIMETextHTML) == fiber.MIMETextHTML {
+			return "html"
+		}
+		return "json"
+	}
+	switch c.Accepts(fiber.MIMETextHTML, fiber.MIMEApplicationJSON, "application/problem+json") {
+	case fiber.MIMEApplicationJSON, "application/problem+json":
+		return "json"
+	}
+	return "html"
+}
+
+// sendError writes an error response as HTML or JSON, following
+// preferredErrorFormat, with JSON in the configured error format.
+func sendError(c *fiber.Ctx, status int, message string) error {
+	c.Status(status)
+	c.Vary(fiber.HeaderAccept)
+	if preferredErrorFormat(c) == "html" {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(fmt.Sprintf(errorPage, status, http.StatusText(status), html.EscapeString(message)))
+	}
+	return sendJSONError(c, status, message)
+}
+
+// sendJSONError writes an error response as JSON in the configured error
+// format, whatever the client accepts.
+func sendJSONError(c *fiber.Ctx, status int, message string) error {
+	c.Status(status)
+	if problemDetails {
+		return c.JSON(fiber.Map{
+			"type":     "about:blank",
+			"title":    http.StatusText(status),
+			"status":   status,
+			"detail":   message,
+			"instance": c.OriginalURL(),
+		}, "application/problem+json")
+	}
+	return c.JSON(fiber.Map{
+		"error": message,
+	})
+}
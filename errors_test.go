@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPreferredErrorFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		query  string
+		want   string
+	}{
+		{"", "", "html"},
+		{"*/*", "", "html"},
+		{"text/html", "", "html"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "", "html"},
+		{"text/plain", "", "html"},
+		{"application/json", "", "json"},
+		{"application/problem+json", "", "json"},
+		{"application/json, */*;q=0.1", "", "json"},
+		{"text/html;q=0.5, application/json", "", "json"},
+		{"application/json;q=0.5, text/html", "", "html"},
+		{"", "json", "json"},
+		{"application/json", "html", "html"},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			return c.SendString(preferredErrorFormat(c))
+		})
+		req := httptest.NewRequest(http.MethodGet, "/?format="+tt.query, nil)
+		if tt.accept != "" {
+			req.Header.Set(fiber.HeaderAccept, tt.accept)
+		}
+		if _, got := send(t, app, req); got != tt.want {
+			t.Errorf("Accept %q, ?format=%s: %s, want %s", tt.accept, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNotFoundDefaultsToHTML(t *testing.T) {
+	templates := fstest.MapFS{"404.html": page("Not Found", "custom not found")}
+	app := newTestApp(t, testRouteConfig(templates, fstest.MapFS{}))
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "text/html; charset=utf-8", "custom not found"},
+		{"*/*", "text/html; charset=utf-8", "custom not found"},
+		{"application/json", "application/json", `"error"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/no-such-page", nil)
+		if tt.accept != "" {
+			req.Header.Set(fiber.HeaderAccept, tt.accept)
+		}
+		resp, body := send(t, app, req)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Accept %q: status %d, want 404", tt.accept, resp.StatusCode)
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type %q, want %q", tt.accept, got, tt.contentType)
+		}
+		if !strings.Contains(body, tt.body) {
+			t.Errorf("Accept %q: body %q does not contain %q", tt.accept, body, tt.body)
+		}
+	}
+}
+
+func TestJSONRouteErrorsDefaultToJSON(t *testing.T) {
+	app := newTestApp(t, testRouteConfig(fstest.MapFS{"404.html": page("Not Found", "custom not found")}, fstest.MapFS{}))
+
+	tests := []struct {
+		path        string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"/api/tutorials/nope", "", http.StatusNotFound, "application/json"},
+		{"/api/tutorials/nope", "*/*", http.StatusNotFound, "application/json"},
+		{"/api/changelog", "", http.StatusNotFound, "application/json"},
+		{"/api/changelog", "*/*", http.StatusNotFound, "application/json"},
+		{"/api/no-such-endpoint", "*/*", http.StatusNotFound, "application/json"},
+		{"/health?fields=uptime", "", http.StatusBadRequest, "application/json"},
+		{"/health?fields=uptime", "*/*", http.StatusBadRequest, "application/json"},
+		{"/api/changelog", "text/html", http.StatusNotFound, "text/html; charset=utf-8"},
+		{"/health?fields=uptime", "text/html,*/*;q=0.8", http.StatusBadRequest, "text/html; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set(fiber.HeaderAccept, tt.accept)
+		}
+		resp, body := send(t, app, req)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s, Accept %q: status %d, want %d", tt.path, tt.accept, resp.StatusCode, tt.status)
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); got != tt.contentType {
+			t.Errorf("GET %s, Accept %q: Content-Type %q, want %q", tt.path, tt.accept, got, tt.contentType)
+		}
+		if tt.contentType == "application/json" && !json.Valid([]byte(body)) {
+			t.Errorf("GET %s, Accept %q: body %q is not JSON", tt.path, tt.accept, body)
+		}
+	}
+}
+
+func TestMiddlewareErrorsOnJSONRoutesAreJSON(t *testing.T) {
+	app := setupFiber()
+	setupRoutes(app, testRouteConfig(fstest.MapFS{"404.html": page("Not Found", "custom not found")}, fstest.MapFS{}))
+
+	// The query guard rejects these before any route runs
+	for _, path := range []string{"/health?format=xml", "/api/changelog?format=xml", "/?format=xml"} {
+		resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, path, nil))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want 400", path, resp.StatusCode)
+		}
+		want := "application/json"
+		if path == "/?format=xml" {
+			want = "text/html; charset=utf-8"
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); got != want {
+			t.Errorf("GET %s: Content-Type %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	problemDetails = true
+	t.Cleanup(func() { problemDetails = false })
+
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	app.Get("/private", requireToken("secret"))
+
+	resp, body := getJSON(t, app, "/private?format=json")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderContentType); got != "application/problem+json" {
+		t.Errorf("Content-Type %q, want application/problem+json", got)
+	}
+	type problem struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail"`
+		Instance string `json:"instance"`
+	}
+	var got problem
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	want := problem{"about:blank", "Unauthorized", http.StatusUnauthorized, "Invalid or missing token", "/private?format=json"}
+	if got != want {
+		t.Errorf("problem %+v, want %+v", got, want)
+	}
+
+	// Browsers still get the HTML page
+	resp, _ = getPage(t, app, "/private")
+	if got := resp.Header.Get(fiber.HeaderContentType); got != "text/html; charset=utf-8" {
+		t.Errorf("HTML client: Content-Type %q", got)
+	}
+}
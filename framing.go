@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// rejectAmbiguousFraming refuses requests whose body length is ambiguous:
+// Content-Length together with Transfer-Encoding, or several Content-Length
+// headers that disagree. fasthttp silently picks one interpretation, which a
+// proxy in front of us might not share (request smuggling), so we check the
+// raw headers ourselves and close the connection on a mismatch.
+func rejectAmbiguousFraming(c *fiber.Ctx) error {
+	var (
+		contentLength    []byte
+		hasContentLength bool
+		hasTransferEnc   bool
+		conflicting      bool
+	)
+
+	for _, line := range bytes.Split(c.Request().Header.RawHeaders(), []byte("\r\n")) {
+		name, value, ok := bytes.Cut(line, []byte(":"))
+		if !ok {
+			continue
+		}
+		name = bytes.TrimSpace(name)
+		value = bytes.TrimSpace(value)
+
+		switch {
+		case bytes.EqualFold(name, []byte(fiber.HeaderContentLength)):
+			if hasContentLength && !bytes.Equal(value, contentLength) {
+				conflicting = true
+			}
+			contentLength, hasContentLength = value, true
+		case bytes.EqualFold(name, []byte(fiber.HeaderTransferEncoding)):
+			hasTransferEnc = true
+		}
+	}
+
+	if hasContentLength && hasTransferEnc {
+		c.Context().SetConnectionClose()
+		return fiber.NewError(fiber.StatusBadRequest, "Conflicting Content-Length and Transfer-Encoding headers")
+	}
+	if conflicting {
+		c.Context().SetConnectionClose()
+		return fiber.NewError(fiber.StatusBadRequest, "Conflicting Content-Length headers")
+	}
+	return c.Next()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sendRaw writes a raw HTTP request to app over a real connection, since
+// net/http will not send conflicting framing headers, and returns the status.
+func sendRaw(t *testing.T, addr, request string) int {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestRejectAmbiguousFraming(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(rejectAmbiguousFraming)
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	tests := []struct {
+		name    string
+		headers string
+		body    string
+		status  int
+	}{
+		{"content-length", "Content-Length: 5\r\n", "hello", http.StatusOK},
+		{"chunked", "Transfer-Encoding: chunked\r\n", "5\r\nhello\r\n0\r\n\r\n", http.StatusOK},
+		{"repeated content-length", "Content-Length: 5\r\nContent-Length: 5\r\n", "hello", http.StatusOK},
+		{"content-length and chunked", "Content-Length: 5\r\nTransfer-Encoding: chunked\r\n", "5\r\nhello\r\n0\r\n\r\n", http.StatusBadRequest},
+		{"conflicting content-lengths", "Content-Length: 5\r\nContent-Length: 6\r\n", "hello!", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		request := "POST / HTTP/1.1\r\nHost: localhost\r\n" + tt.headers + "\r\n" + tt.body
+		if got := sendRaw(t, ln.Addr().String(), request); got != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.status)
+		}
+	}
+}
@@ -2,7 +2,10 @@ module github.com/momokii/go-cli-notes-web
 
 go 1.24
 
-require github.com/gofiber/fiber/v2 v2.52.6
+require (
+	github.com/gofiber/fiber/v2 v2.52.6
+	golang.org/x/sys v0.28.0
+)
 
 require (
 	github.com/andybalholm/brotli v1.1.1 // indirect
@@ -17,5 +20,4 @@ require (
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasthttp v1.51.0 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
 )
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"net/textproto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// singleValueHeaders must appear at most once per response. Browsers treat
+// repeated values inconsistently (or, for CSP, intersect them).
+var singleValueHeaders = map[string]bool{
+	fiber.HeaderContentType:             true,
+	fiber.HeaderXContentTypeOptions:     true,
+	fiber.HeaderXFrameOptions:           true,
+	fiber.HeaderXXSSProtection:          true,
+	fiber.HeaderReferrerPolicy:          true,
+	fiber.HeaderPermissionsPolicy:       true,
+	fiber.HeaderContentSecurityPolicy:   true,
+	fiber.HeaderStrictTransportSecurity: true,
+	fiber.HeaderCacheControl:            true,
+	"X-Application-Version":             true,
+}
+
+// normalizeHeaders runs after every other middleware and collapses repeated
+// single-value headers to their last value. With warn set, each collapse is
+// logged so the middleware that duplicated it can be fixed.
+func normalizeHeaders(warn bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		header := &c.Response().Header
+		values := make(map[string][]string)
+		header.VisitAll(func(key, value []byte) {
+			name := textproto.CanonicalMIMEHeaderKey(string(key))
+			if singleValueHeaders[name] {
+				values[name] = append(values[name], string(value))
+			}
+		})
+
+		for name, vals := range values {
+			if len(vals) < 2 {
+				continue
+			}
+			// Set only replaces the first occurrence, so clear them all first
+			header.Del(name)
+			header.Set(name, vals[len(vals)-1])
+			if warn {
+				log.Printf("Warning: collapsed %d %s headers on %s %s", len(vals), name, c.Method(), c.Path())
+			}
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestNormalizeHeadersCollapsesDuplicates(t *testing.T) {
+	logs := captureLog(t)
+	app := fiber.New()
+	app.Use(normalizeHeaders(true))
+	app.Get("/", func(c *fiber.Ctx) error {
+		header := &c.Response().Header
+		header.Add(fiber.HeaderXFrameOptions, "SAMEORIGIN")
+		header.Add(fiber.HeaderXFrameOptions, "DENY")
+		header.Add(fiber.HeaderContentSecurityPolicy, "default-src *")
+		header.Add(fiber.HeaderContentSecurityPolicy, "default-src 'self'")
+		header.Add(fiber.HeaderLink, "</a.css>; rel=preload")
+		header.Add(fiber.HeaderLink, "</b.css>; rel=preload")
+		return c.SendString("ok")
+	})
+
+	resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, "/", nil))
+	tests := map[string][]string{
+		fiber.HeaderXFrameOptions:           {"DENY"},
+		fiber.HeaderContentSecurityPolicy:   {"default-src 'self'"},
+		fiber.HeaderLink:                    {"</a.css>; rel=preload", "</b.css>; rel=preload"},
+		fiber.HeaderXContentTypeOptions:     nil,
+		fiber.HeaderStrictTransportSecurity: nil,
+	}
+	for name, want := range tests {
+		if got := resp.Header.Values(name); !slices.Equal(got, want) {
+			t.Errorf("%s: %q, want %q", name, got, want)
+		}
+	}
+	if n := strings.Count(logs.String(), "Warning: collapsed 2"); n != 2 {
+		t.Errorf("logged %d collapses, want 2:\n%s", n, logs)
+	}
+}
+
+func TestSetupFiberSendsEachSecurityHeaderOnce(t *testing.T) {
+	t.Setenv("CSP_NONCE", "true")
+	app := setupFiber()
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString("<p>ok</p>")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderOrigin, "https://notes.example.com")
+	resp, _ := send(t, app, req)
+	for name := range singleValueHeaders {
+		if got := resp.Header.Values(name); len(got) > 1 {
+			t.Errorf("%s sent %d times: %q", name, len(got), got)
+		}
+	}
+	if got := resp.Header.Values(fiber.HeaderAccessControlAllowOrigin); len(got) != 1 {
+		t.Errorf("Access-Control-Allow-Origin sent %d times: %q", len(got), got)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// healthSchemaVersion versions the /health response body. Fields are only
+// ever added within a version; removing or changing the meaning of a field
+// requires bumping it.
+const healthSchemaVersion = 1
+
+// healthHandler is the liveness check. It never touches the network or disk.
+// ?fields=status,version limits the body to the listed fields.
+func healthHandler(c *fiber.Ctx) error {
+	body := fiber.Map{
+		"status":         "healthy",
+		"version":        version,
+		"app":            appName,
+		"schema_version": healthSchemaVersion,
+	}
+
+	fields := splitList(c.Query("fields"))
+	if len(fields) == 0 {
+		return c.JSON(body)
+	}
+
+	subset := make(fiber.Map, len(fields))
+	for _, field := range fields {
+		value, ok := body[field]
+		if !ok {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Unknown health field %q", field))
+		}
+		subset[field] = value
+	}
+	return c.JSON(subset)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHealthHandler(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	app.Get("/health", healthHandler)
+
+	tests := []struct {
+		query  string
+		status int
+		want   map[string]any
+	}{
+		{"", http.StatusOK, map[string]any{
+			"status":         "healthy",
+			"version":        version,
+			"app":            appName,
+			"schema_version": float64(healthSchemaVersion),
+		}},
+		{"?fields=status,schema_version", http.StatusOK, map[string]any{
+			"status":         "healthy",
+			"schema_version": float64(healthSchemaVersion),
+		}},
+		{"?fields=status,uptime", http.StatusBadRequest, map[string]any{
+			"error": `Unknown health field "uptime"`,
+		}},
+	}
+	for _, tt := range tests {
+		resp, body := getJSON(t, app, "/health"+tt.query)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET /health%s: status %d, want %d", tt.query, resp.StatusCode, tt.status)
+		}
+		var got map[string]any
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("GET /health%s: decode %q: %v", tt.query, body, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET /health%s = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
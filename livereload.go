@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const liveReloadKey = "liveReload"
+
+// liveReloadScript long-polls /livereload with the last seen templates version
+// and reloads the page once the server reports a different one.
+const liveReloadScript = `<script nonce="` + cspNoncePlaceholder + `">
+(function () {
+    var version = "";
+    function poll() {
+        fetch("/livereload?since=" + encodeURIComponent(version))
+            .then(function (res) { return res.json(); })
+            .then(function (data) {
+                if (version && data.version !== version) {
+                    location.reload();
+                    return;
+                }
+                version = data.version;
+                poll();
+            })
+            .catch(function () { setTimeout(poll, 2000); });
+    }
+    poll();
+})();
+</script>
+`
+
+// liveReloadWait bounds each /livereload poll. fasthttp cannot tell a handler
+// that its client went away, so a poll from a closed tab runs until this
+// wait; keeping it short bounds that, and stays under the default
+// SHUTDOWN_TIMEOUT so open polls never hold up a shutdown.
+var (
+	liveReloadWait     = 4 * time.Second
+	liveReloadInterval = 500 * time.Millisecond
+)
+
+// liveReload marks the request so serveTemplate injects the reload script.
+// It is only registered in development.
+func liveReload(c *fiber.Ctx) error {
+	c.Locals(liveReloadKey, true)
+	return c.Next()
+}
+
+// injectLiveReload adds the reload script before </body> when the request was
+// marked by the liveReload middleware.
+func injectLiveReload(c *fiber.Ctx, content []byte) []byte {
+	if enabled, _ := c.Locals(liveReloadKey).(bool); !enabled {
+		return content
+	}
+	i := bytes.LastIndex(content, []byte("</body>"))
+	if i < 0 {
+		return content
+	}
+	out := make([]byte, 0, len(content)+len(liveReloadScript))
+	out = append(out, content[:i]...)
+	out = append(out, liveReloadScript...)
+	return append(out, content[i:]...)
+}
+
+// templatesVersion fingerprints the templates from the newest modification
+// time and the number of files. Embedded templates always report the same
+// version, so live reload only fires with TEMPLATES_DIR.
+func templatesVersion(fsys fs.FS) string {
+	var latest int64
+	var count int
+	_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			count++
+			latest = max(latest, info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return fmt.Sprintf("%d-%d", latest, count)
+}
+
+// templateWatcher applies template edits for live reload. Templates are only
+// replaced through a validated reload, so the first poll that sees a new
+// templates version runs it before any browser is told to refresh.
+type templateWatcher struct {
+	fsys   fs.FS
+	reload func() error
+
+	mu      sync.Mutex
+	applied string
+}
+
+func newTemplateWatcher(fsys fs.FS, reload func() error) *templateWatcher {
+	return &templateWatcher{fsys: fsys, reload: reload, applied: templatesVersion(fsys)}
+}
+
+// version returns the current templates version, reloading the templates
+// first if it changed since the last reload.
+func (w *templateWatcher) version() string {
+	current := templatesVersion(w.fsys)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if current != w.applied {
+		w.applied = current
+		if err := w.reload(); err != nil {
+			log.Printf("Live reload of templates failed: %v", err)
+		}
+	}
+	return current
+}
+
+// liveReloadHandler answers as soon as the templates version differs from
+// ?since, or with the current version after liveReloadWait, whichever comes
+// first. The script then polls again.
+func liveReloadHandler(templates *templateWatcher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		since := c.Query("since")
+		deadline := time.Now().Add(liveReloadWait)
+		for {
+			current := templates.version()
+			if since == "" || current != since || !time.Now().Before(deadline) {
+				return c.JSON(fiber.Map{"version": current})
+			}
+			time.Sleep(min(liveReloadInterval, time.Until(deadline)))
+		}
+	}
+}
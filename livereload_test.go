@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLiveReloadOnlyInDevelopment(t *testing.T) {
+	tests := []struct {
+		env        string
+		liveReload string
+		want       bool
+	}{
+		{"development", "true", true},
+		{"development", "false", false},
+		{"production", "true", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("ENV", tt.env)
+		t.Setenv("LIVE_RELOAD", tt.liveReload)
+		cfg, err := loadRouteConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		test := testRouteConfig(fstest.MapFS{"index.html": page("Home", "home")}, fstest.MapFS{})
+		cfg.templates, cfg.static, cfg.changelog = test.templates, test.static, test.changelog
+		app := setupFiber()
+		setupRoutes(app, cfg)
+
+		_, body := getPage(t, app, "/")
+		if got := strings.Contains(body, "/livereload?since="); got != tt.want {
+			t.Errorf("ENV=%s LIVE_RELOAD=%s: reload script injected = %v, want %v", tt.env, tt.liveReload, got, tt.want)
+		}
+		resp, _ := getJSON(t, app, "/livereload")
+		if got := resp.StatusCode == http.StatusOK; got != tt.want {
+			t.Errorf("ENV=%s LIVE_RELOAD=%s: /livereload status %d", tt.env, tt.liveReload, resp.StatusCode)
+		}
+	}
+}
+
+func TestTemplateWatcherReloadsOnChange(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte("v1"), ModTime: time.Unix(1, 0)}}
+	reloads := 0
+	watcher := newTemplateWatcher(fsys, func() error {
+		reloads++
+		return nil
+	})
+
+	first := watcher.version()
+	if watcher.version() != first || reloads != 0 {
+		t.Fatalf("unchanged templates: %d reloads, want 0", reloads)
+	}
+
+	fsys["index.html"].ModTime = time.Unix(2, 0)
+	if watcher.version() == first {
+		t.Error("version did not change after an edit")
+	}
+	watcher.version()
+	if reloads != 1 {
+		t.Errorf("%d reloads after one edit, want 1", reloads)
+	}
+}
+
+func TestLiveReloadHandlerIsBounded(t *testing.T) {
+	prevWait, prevInterval := liveReloadWait, liveReloadInterval
+	liveReloadWait, liveReloadInterval = 200*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { liveReloadWait, liveReloadInterval = prevWait, prevInterval })
+
+	fsys := fstest.MapFS{"index.html": {Data: []byte("v1"), ModTime: time.Unix(1, 0)}}
+	watcher := newTemplateWatcher(fsys, func() error { return nil })
+	app := fiber.New()
+	app.Get("/livereload", liveReloadHandler(watcher))
+	first := watcher.version()
+
+	poll := func() (string, time.Duration) {
+		t.Helper()
+		start := time.Now()
+		_, body := getJSON(t, app, "/livereload?since="+first)
+		var got struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("decode %q: %v", body, err)
+		}
+		return got.Version, time.Since(start)
+	}
+
+	// Nothing changes: the poll ends at the wait, not whenever the client leaves
+	version, elapsed := poll()
+	if version != first {
+		t.Errorf("unchanged templates: version %q, want %q", version, first)
+	}
+	if elapsed < liveReloadWait || elapsed > liveReloadWait+100*time.Millisecond {
+		t.Errorf("unchanged templates: answered after %s, want about %s", elapsed, liveReloadWait)
+	}
+
+	// An edit is reported without waiting
+	fsys["index.html"].ModTime = time.Unix(2, 0)
+	version, elapsed = poll()
+	if version == first {
+		t.Error("edit not reported")
+	}
+	if elapsed >= liveReloadWait {
+		t.Errorf("edit reported after %s, want before the %s wait", elapsed, liveReloadWait)
+	}
+}
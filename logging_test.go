@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSyncLog(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "access.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := syncLog(f)(context.Background()); err != nil {
+		t.Errorf("sync file: %v", err)
+	}
+
+	// Pipes cannot be synced; that is not a shutdown failure
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if err := syncLog(w)(context.Background()); err != nil {
+		t.Errorf("sync pipe: %v", err)
+	}
+
+	f.Close()
+	if err := syncLog(f)(context.Background()); err == nil {
+		t.Error("sync closed file: got nil error")
+	}
+}
+
+// captureStdout points os.Stdout at a temporary file for the rest of the
+// test and returns a function reading what was written to it.
+func captureStdout(t *testing.T) func() string {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdout
+	os.Stdout = f
+	t.Cleanup(func() {
+		os.Stdout = prev
+		f.Close()
+	})
+	return func() string {
+		content, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+}
+
+func TestRequestLoggerSkipsNotModified(t *testing.T) {
+	for _, logNotModified := range []string{"true", "false"} {
+		t.Run("LOG_NOT_MODIFIED="+logNotModified, func(t *testing.T) {
+			t.Setenv("LOG_NOT_MODIFIED", logNotModified)
+			output := captureStdout(t)
+
+			app := fiber.New()
+			app.Use(newRequestLogger())
+			app.Get("/page", func(c *fiber.Ctx) error {
+				return c.SendString("page")
+			})
+			app.Get("/cached", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusNotModified)
+			})
+
+			send(t, app, httptest.NewRequest(http.MethodGet, "/page", nil))
+			send(t, app, httptest.NewRequest(http.MethodGet, "/cached", nil))
+
+			logged := output()
+			if !strings.Contains(logged, "/page") {
+				t.Errorf("200 not logged:\n%s", logged)
+			}
+			if got, want := strings.Contains(logged, "/cached"), logNotModified == "true"; got != want {
+				t.Errorf("304 logged = %v, want %v:\n%s", got, want, logged)
+			}
+		})
+	}
+}
+
+func TestJSONLinesAccessLog(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "json")
+	output := captureStdout(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	app.Use(newRequestLogger())
+	app.Get("/page", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+	app.Get("/private", requireToken("secret"))
+
+	send(t, app, httptest.NewRequest(http.MethodGet, "/page", nil))
+	send(t, app, httptest.NewRequest(http.MethodGet, "/private", nil))
+
+	lines := strings.Split(strings.TrimSuffix(output(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	want := []accessLogEntry{
+		{Status: 200, Method: "GET", Path: "/page", Bytes: 5},
+		{Status: 401, Method: "GET", Path: "/private", Error: "Invalid or missing token"},
+	}
+	for i, line := range lines {
+		var entry accessLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line %d %q: %v", i, line, err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, entry.Time); err != nil {
+			t.Errorf("line %d: time %q: %v", i, entry.Time, err)
+		}
+		if entry.LatencyMS < 0 {
+			t.Errorf("line %d: latency %v", i, entry.LatencyMS)
+		}
+		if entry.Status != want[i].Status || entry.Method != want[i].Method || entry.Path != want[i].Path || entry.Error != want[i].Error {
+			t.Errorf("line %d: %+v, want %+v", i, entry, want[i])
+		}
+		if want[i].Bytes > 0 && entry.Bytes != want[i].Bytes {
+			t.Errorf("line %d: %d bytes, want %d", i, entry.Bytes, want[i].Bytes)
+		}
+	}
+}
@@ -1,19 +1,22 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"log"
-	"net/http"
+	"math/bits"
+	"net"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
@@ -23,161 +26,325 @@ var (
 )
 
 func main() {
-	// Get working directory for absolute paths
-	wd, err := os.Getwd()
+	cfg, err := loadRouteConfig()
 	if err != nil {
-		log.Fatalf("Failed to get working directory: %v", err)
+		log.Fatalf("Invalid %v", err)
+	}
+
+	if err := configureSiteURL(getEnv("SITE_URL", "")); err != nil {
+		log.Fatalf("Invalid SITE_URL: %v", err)
+	}
+
+	tlsConfig, err := parseTLSConfig(
+		getEnv("TLS_CERT_FILE", ""),
+		getEnv("TLS_KEY_FILE", ""),
+		getEnv("TLS_MIN_VERSION", "1.2"),
+		getEnv("TLS_CIPHER_SUITES", ""),
+	)
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
 	}
 
 	app := setupFiber()
-	setupRoutes(app, wd)
-	setupGracefulShutdown(app)
+	reloads := setupRoutes(app, cfg)
+	shutdownDone := setupGracefulShutdown(app)
+	onShutdown("request log", syncLog(os.Stdout))
+	setupReloadSignal(reloads)
 
 	port := getEnv("PORT", "3000")
 	log.Printf("Starting %s on port %s", appName, port)
 
-	if err := app.Listen(":" + port); err != nil {
+	if err := listen(app, ":"+port, tlsConfig); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
+
+	// Listen returns as soon as shutdown begins; wait for hooks to flush
+	<-shutdownDone
+}
+
+// listen serves app on addr, sharing the port with other instances through
+// SO_REUSEPORT when REUSEPORT=true, and over HTTPS when tlsConfig is set.
+func listen(app *fiber.App, addr string, tlsConfig *tls.Config) error {
+	reusePort := getEnvBool("REUSEPORT", false)
+	if !reusePort && tlsConfig == nil {
+		return app.Listen(addr)
+	}
+
+	var ln net.Listener
+	var err error
+	if reusePort {
+		ln, err = listenReusePort(app.Config().Network, addr)
+	} else {
+		ln, err = net.Listen(app.Config().Network, addr)
+	}
+	if err != nil {
+		return err
+	}
+	if reusePort {
+		log.Printf("Listening on %s with SO_REUSEPORT", addr)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+		log.Printf("Serving HTTPS, minimum %s", tls.VersionName(tlsConfig.MinVersion))
+	}
+	return app.Listener(ln)
 }
 
 func setupFiber() *fiber.App {
+	problemDetails = getEnvBool("PROBLEM_DETAILS", false)
+	featureOverridesEnabled = isDevelopment() && getEnvBool("FEATURE_OVERRIDES", true)
+
 	app := fiber.New(fiber.Config{
 		AppName:               appName,
 		DisableStartupMessage: false,
-		EnablePrintRoutes:     getEnv("ENV", "development") == "development",
+		EnablePrintRoutes:     isDevelopment(),
 		ErrorHandler:          customErrorHandler,
-		// Not using template engine - reading HTML files directly with os.ReadFile()
+		// Not using template engine - serving the HTML files as they are
 	})
 
 	// Middleware
-	app.Use(
-		logger.New(logger.Config{
-			// Format:     "[${time}] ${status} - ${method} ${path} (${latency})",
-			// TimeFormat: "2006-01-02 15:04:05",
-			// Output:     os.Stdout,
-		}),
-	)
+	// Header normalisation goes first so it sees every header set below it
+	app.Use(normalizeHeaders(isDevelopment()))
+	app.Use(newRequestLogger())
 
 	app.Use(recover.New())
+	compressRoutes, err := parseCompressRoutes(getEnv("COMPRESS_ROUTES", ""))
+	if err != nil {
+		log.Fatalf("Invalid COMPRESS_ROUTES: %v", err)
+	}
 	app.Use(compress.New(compress.Config{
+		Next:  skipCompression(getEnvBool("COMPRESS", true), compressRoutes),
 		Level: compress.LevelBestSpeed,
 	}))
+	if featureOverridesEnabled {
+		app.Use(applyFeatureOverrides)
+	}
 
+	allowOrigin, err := corsOriginMatcher(getEnv("CORS_ORIGIN_REGEX", ""))
+	if err != nil {
+		log.Fatalf("Invalid CORS_ORIGIN_REGEX: %v", err)
+	}
 	app.Use(cors.New(cors.Config{
+		Next: corsRouteFilter(splitList(getEnv("CORS_ROUTES", ""))),
 		// AllowOrigins:     []string{"https://cli-notes-api.kelanach.xyz", "http://localhost:3000", "http://localhost:8080"},
-		AllowMethods: "GET,POST,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowOriginsFunc: allowOrigin,
+		AllowMethods:     "GET,POST,OPTIONS",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
 		// AllowCredentials: true,
 	}))
 
-	// Security headers
-	app.Use(securityHeaders)
-
-	// Rate limiting: 120 req/min per IP using Fiber's built-in middleware
-	app.Use(limiter.New(limiter.Config{
-		Max:        120,
-		Expiration: 1 * time.Minute,
-		KeyGenerator: func(c *fiber.Ctx) string {
-			return c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
-				"error": "Rate limit exceeded",
-			})
-		},
-	}))
+	// Security headers, exposing the version header only where configured
+	app.Use(securityHeaders(
+		getEnvBool("EXPOSE_VERSION_HEADER", isDevelopment()),
+		getEnvBool("SECURITY_HEADERS_HTML_ONLY", false),
+	))
+
+	// Request guards sit behind the security headers so their 400s carry them
+	app.Use(rejectAmbiguousFraming)
+	app.Use(validateQuery)
+	if getEnvBool("CSP_NONCE", false) {
+		app.Use(cspNonce)
+	}
+
+	// Template live reload, never enabled outside development
+	if isDevelopment() && getEnvBool("LIVE_RELOAD", false) {
+		app.Use(liveReload)
+	}
+
+	// Rate limiting: RATE_LIMIT req/min per IP, optionally tightened under load
+	setupRateLimiting(app)
+
+	// Per-route hit counters and latencies, only recorded when /stats is on
+	if getEnvBool("ENABLE_STATS", false) {
+		buckets := getEnvInt("STATS_LATENCY_BUCKETS", 8)
+		if buckets < 0 || buckets > 64 || bits.OnesCount(uint(buckets)) > 1 {
+			log.Fatalf("Invalid STATS_LATENCY_BUCKETS: %d must be 0 or a power of two up to 64", buckets)
+		}
+		hitStats.latencyBuckets = buckets
+		app.Use(countHits)
+	}
+
+	// Deprecation/Sunset headers for routes slated for removal
+	deprecated, err := parseDeprecatedRoutes(getEnv("DEPRECATED_ROUTES", ""), time.Now())
+	if err != nil {
+		log.Fatalf("Invalid DEPRECATED_ROUTES: %v", err)
+	}
+	if len(deprecated) > 0 {
+		app.Use(deprecationHeaders(deprecated))
+	}
 
 	return app
 }
 
-func securityHeaders(c *fiber.Ctx) error {
-	c.Set("X-Content-Type-Options", "nosniff")
+// corsRouteFilter limits CORS to requests under one of the given path prefixes.
+// With no prefixes CORS applies to every route.
+func corsRouteFilter(prefixes []string) func(c *fiber.Ctx) bool {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	return func(c *fiber.Ctx) bool {
+		for _, prefix := range prefixes {
+			if hasPathPrefix(c.Path(), prefix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// corsOriginMatcher compiles pattern into an origin check for CORS. The
+// pattern must match the whole origin, case-insensitively, so
+// https://.*\.example\.com does not allow
+// https://preview.example.com.evil.net. An empty pattern returns nil, which
+// allows every origin.
+func corsOriginMatcher(pattern string) (func(origin string) bool, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(`(?i)^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// hasPathPrefix reports whether path is prefix or lies beneath it.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+// securityHeaders sets the security response headers. With htmlOnly, headers
+// that only matter for documents (framing, XSS, referrer, permissions, CSP)
+// are limited to text/html responses, leaving API and asset responses with a
+// minimal set.
+func securityHeaders(exposeVersion, htmlOnly bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("X-Content-Type-Options", "nosniff")
+		if exposeVersion {
+			c.Set("X-Application-Version", version)
+		}
+
+		if !htmlOnly {
+			setDocumentSecurityHeaders(c)
+			return c.Next()
+		}
+
+		// The content type is only known once the handler has run, and for
+		// errors only once the error handler has rendered them
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+		if strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMETextHTML) {
+			setDocumentSecurityHeaders(c)
+		} else {
+			c.Response().Header.Del("Content-Security-Policy")
+		}
+		return nil
+	}
+}
+
+func setDocumentSecurityHeaders(c *fiber.Ctx) {
 	c.Set("X-Frame-Options", "DENY")
 	c.Set("X-XSS-Protection", "1; mode=block")
 	c.Set("Referrer-Policy", "strict-origin-when-cross-origin")
 	c.Set("Permissions-Policy", "default-src 'self'")
-	c.Set("X-Application-Version", version)
-	return c.Next()
 }
 
-func setupRoutes(app *fiber.App, wd string) {
-	// Build absolute paths
-	staticPath := filepath.Join(wd, "static")
-	templatesPath := filepath.Join(wd, "templates")
+// setupRoutes registers every route on app, serving from the file systems in
+// cfg. It returns the reloader holding the reload hooks for templates and
+// the changelog.
+func setupRoutes(app *fiber.App, cfg routeConfig) *reloader {
+	reloads := &reloader{}
+
+	templates := newAssetCache(cfg.templates, decodeTemplate, false)
+	templates.serveStale = cfg.serveStaleOnError
+	templates.preload()
+	static := newAssetCache(cfg.static, nil, true)
+	static.serveStale = cfg.serveStaleOnError
+	if cfg.validateAssets {
+		for _, missing := range missingAssets(templates, static) {
+			log.Printf("Warning: %s", missing)
+		}
+	}
+
+	// HTML pages: the main page, the tutorial hub and the tutorial guides
+	pages := pageRoutes()
+	reloadPages := reloadTemplates(templates, pages)
 
 	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"version": version,
-			"app":     appName,
-		})
-	})
+	app.Get("/health", healthHandler)
+
+	// Readiness check against the upstream API
+	app.Get("/ready", readyHandler(cfg.ready))
+
+	// Operator status page
+	if cfg.statusEnabled {
+		app.Get("/status", requireToken(cfg.statusToken), statusHandler(cfg.ready, cfg.statsEnabled))
+	}
+
+	// Per-route hit counts and latency percentiles
+	if cfg.statsEnabled {
+		app.Get("/stats", statsHandler)
+	}
+
+	// Go runtime stats via expvar
+	if cfg.debugVarsEnabled {
+		setupDebugVars(app, cfg.debugVarsToken)
+	}
+
+	// Live reload polling for the injected dev script
+	if cfg.liveReload {
+		app.Get("/livereload", liveReloadHandler(newTemplateWatcher(cfg.templates, reloadPages)))
+	}
 
 	// Static files
-	app.Static("/static", staticPath)
-
-	// Main page - uses index.html as the single template
-	app.Get("/", func(c *fiber.Ctx) error {
-		c.Set("Content-Type", "text/html; charset=utf-8")
-		content, err := os.ReadFile(filepath.Join(templatesPath, "index.html"))
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("Template not found")
-		}
-		return c.SendString(string(content))
-	})
+	app.Get("/static/*", staticHandler(static, cfg.mimeTypes))
 
-	// Tutorial hub
-	app.Get("/tutorial", func(c *fiber.Ctx) error {
-		c.Set("Content-Type", "text/html; charset=utf-8")
-		content, err := os.ReadFile(filepath.Join(templatesPath, "tutorial.html"))
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("Template not found")
-		}
-		return c.SendString(string(content))
-	})
+	// Release notes from CHANGELOG.md, reloaded on SIGHUP
+	setupChangelog(app, cfg.changelog, cfg.changelogFile, reloads)
 
-	// Self-hosting tutorial
-	app.Get("/tutorial/self-hosting", func(c *fiber.Ctx) error {
-		c.Set("Content-Type", "text/html; charset=utf-8")
-		content, err := os.ReadFile(filepath.Join(templatesPath, "tutorial-self-hosting.html"))
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("Template not found")
-		}
-		return c.SendString(string(content))
-	})
+	// Human-readable site index
+	app.Get("/sitemap", siteMapHandler(templates))
 
-	// CLI reference
-	app.Get("/tutorial/cli-reference", func(c *fiber.Ctx) error {
-		c.Set("Content-Type", "text/html; charset=utf-8")
-		content, err := os.ReadFile(filepath.Join(templatesPath, "tutorial-cli-reference.html"))
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("Template not found")
+	// All tutorial guides as one printable document
+	app.Get("/tutorial/all", tutorialBundleHandler(templates))
+
+	// Draft guides are hidden unless previewed, ahead of every variant below
+	for _, page := range tutorialPages {
+		if page.Draft {
+			app.Get(page.Path, hideDraft(templates, cfg.previewToken))
 		}
-		return c.SendString(string(content))
-	})
+	}
 
-	// TUI guide
-	app.Get("/tutorial/tui", func(c *fiber.Ctx) error {
-		c.Set("Content-Type", "text/html; charset=utf-8")
-		content, err := os.ReadFile(filepath.Join(templatesPath, "tutorial-tui.html"))
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("Template not found")
+	// Plain text variants, negotiated ahead of the HTML pages below
+	plaintext := newPlaintextCache()
+	for _, page := range tutorialPages {
+		if page.PlainText {
+			app.Get(page.Path, plainTextHandler(plaintext, templates, page.Template))
 		}
-		return c.SendString(string(content))
-	})
+	}
+
+	// HTML pages, replaced by validated reloads
+	registerTemplateRoutes(app, templates, pages)
+	reloads.add("templates", reloadPages)
+
+	// Reload and validate templates and the changelog on demand
+	if cfg.adminToken != "" {
+		app.Post("/admin/reload", requireToken(cfg.adminToken), adminReloadHandler(reloads))
+	}
+
+	// Tutorial content as JSON
+	app.Get("/api/tutorials/:slug", tutorialAPIHandler(templates, cfg.previewToken))
+
+	// CLI commands and flags parsed from the CLI reference
+	app.Get("/api/cli/commands", cliCommandsHandler(templates))
 
 	// 404 handler - must be last
-	app.Use(func(c *fiber.Ctx) error {
-		c.Set("Content-Type", "text/html; charset=utf-8")
-		content, err := os.ReadFile(filepath.Join(templatesPath, "404.html"))
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("404 - Page Not Found")
-		}
-		c.Status(fiber.StatusNotFound)
-		return c.SendString(string(content))
-	})
+	app.Use(notFoundHandler(templates))
+
+	return reloads
 }
 
 func customErrorHandler(c *fiber.Ctx, err error) error {
@@ -187,30 +354,35 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 		code = e.Code
 	}
 
-	c.Set("Content-Type", "application/json")
-
-	if code == fiber.StatusNotFound {
-		c.Status(fiber.StatusNotFound)
-		return c.SendString("404 - Page Not Found")
-	}
-
-	c.Status(code)
-	return c.JSON(fiber.Map{
-		"error": err.Error(),
-	})
+	return sendError(c, code, err.Error())
 }
 
-func setupGracefulShutdown(app *fiber.App) {
+// setupGracefulShutdown stops the server on SIGINT/SIGTERM, then runs the
+// shutdown hooks. The returned channel is closed once everything has finished.
+func setupGracefulShutdown(app *fiber.App) <-chan struct{} {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
 
 	go func() {
 		<-c
+		defer close(done)
 		log.Println("Shutting down server...")
-		if err := app.ShutdownWithTimeout(5 * time.Second); err != nil {
+
+		ctx, cancel := context.WithTimeout(context.Background(), getEnvDuration("SHUTDOWN_TIMEOUT", 5*time.Second))
+		defer cancel()
+
+		if err := app.ShutdownWithContext(ctx); err != nil {
 			log.Printf("Error during shutdown: %v", err)
 		}
+		runShutdownHooks(ctx)
 	}()
+
+	return done
+}
+
+func isDevelopment() bool {
+	return getEnv("ENV", "development") == "development"
 }
 
 func getEnv(key, defaultValue string) string {
@@ -219,3 +391,38 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// splitList splits a comma-separated value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
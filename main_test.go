@@ -0,0 +1,296 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testRouteConfig serves templates and static from memory, with no changelog
+// and every optional route off.
+func testRouteConfig(templates, static fstest.MapFS) routeConfig {
+	return routeConfig{
+		templates:     templates,
+		static:        static,
+		changelog:     fstest.MapFS{},
+		changelogFile: "CHANGELOG.md",
+	}
+}
+
+// newTestApp returns an app with the production error handler and the routes
+// registered by setupRoutes for cfg.
+func newTestApp(t *testing.T, cfg routeConfig) *fiber.App {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	setupRoutes(app, cfg)
+	return app
+}
+
+// send runs req against app and returns the response with its body read.
+func send(t *testing.T, app *fiber.App, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: read body: %v", req.Method, req.URL, err)
+	}
+	return resp, string(body)
+}
+
+// page builds a minimal HTML template with the given title and body text.
+func page(title, body string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte("<!DOCTYPE html><html><head><title>" + title +
+		"</title></head><body>" + body + "</body></html>")}
+}
+
+func TestSetupRoutesServesFromFS(t *testing.T) {
+	templates := fstest.MapFS{
+		"index.html": page("Home", "welcome home"),
+		"404.html":   page("Not Found", "nothing here"),
+	}
+	static := fstest.MapFS{
+		"css/site.css": {Data: []byte("body { color: black; }")},
+	}
+	app := newTestApp(t, testRouteConfig(templates, static))
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/", http.StatusOK, "text/html; charset=utf-8", "welcome home"},
+		{"/static/css/site.css", http.StatusOK, "text/css; charset=utf-8", "color: black"},
+		{"/static/missing.css", http.StatusNotFound, "text/html; charset=utf-8", "nothing here"},
+		{"/no-such-page", http.StatusNotFound, "text/html; charset=utf-8", "nothing here"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set(fiber.HeaderAccept, "text/html")
+		resp, body := send(t, app, req)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); got != tt.contentType {
+			t.Errorf("GET %s: Content-Type %q, want %q", tt.path, got, tt.contentType)
+		}
+		if !strings.Contains(body, tt.body) {
+			t.Errorf("GET %s: body %q does not contain %q", tt.path, body, tt.body)
+		}
+	}
+}
+
+func TestSetupRoutesReturnsItsOwnReloader(t *testing.T) {
+	cfg := testRouteConfig(fstest.MapFS{"index.html": page("Home", "home")}, fstest.MapFS{})
+	first := setupRoutes(fiber.New(), cfg)
+	second := setupRoutes(fiber.New(), cfg)
+
+	if len(first.hooks) != 2 || len(second.hooks) != 2 {
+		t.Fatalf("got %d and %d reload hooks, want 2 each", len(first.hooks), len(second.hooks))
+	}
+}
+
+func TestSecurityHeadersHTMLOnly(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	app.Use(securityHeaders(false, true))
+	app.Get("/page", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'self'")
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString("<p>page</p>")
+	})
+	app.Get("/api", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'self'")
+		return c.JSON(fiber.Map{"ok": true})
+	})
+	app.Get("/status", requireToken("secret"))
+
+	tests := []struct {
+		path     string
+		accept   string
+		status   int
+		document bool
+	}{
+		{"/page", "text/html", http.StatusOK, true},
+		{"/api", "application/json", http.StatusOK, false},
+		// Errors are rendered by the error handler, after the route returns
+		{"/status", "text/html", http.StatusUnauthorized, true},
+		{"/status", "application/json", http.StatusUnauthorized, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set(fiber.HeaderAccept, tt.accept)
+		resp, _ := send(t, app, req)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s (%s): status %d, want %d", tt.path, tt.accept, resp.StatusCode, tt.status)
+		}
+		if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("GET %s (%s): X-Content-Type-Options %q, want nosniff", tt.path, tt.accept, got)
+		}
+		if got := resp.Header.Get("X-Frame-Options") != ""; got != tt.document {
+			t.Errorf("GET %s (%s): X-Frame-Options sent = %v, want %v", tt.path, tt.accept, got, tt.document)
+		}
+		if tt.path != "/status" {
+			if got := resp.Header.Get(fiber.HeaderContentSecurityPolicy) != ""; got != tt.document {
+				t.Errorf("GET %s (%s): Content-Security-Policy sent = %v, want %v", tt.path, tt.accept, got, tt.document)
+			}
+		}
+	}
+}
+
+func TestSetupFiberGuardsSendSecurityHeaders(t *testing.T) {
+	for _, htmlOnly := range []string{"false", "true"} {
+		t.Setenv("SECURITY_HEADERS_HTML_ONLY", htmlOnly)
+		app := setupFiber()
+		app.Get("/", func(c *fiber.Ctx) error {
+			return c.SendString("ok")
+		})
+
+		tests := []struct {
+			query  string
+			status int
+		}{
+			{"", http.StatusOK},
+			{"?format=html", http.StatusOK},
+			{"?format=xml", http.StatusBadRequest},
+			{"?format=json&format=html", http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			resp, _ := getPage(t, app, "/"+tt.query)
+			if resp.StatusCode != tt.status {
+				t.Errorf("htmlOnly=%s GET /%s: status %d, want %d", htmlOnly, tt.query, resp.StatusCode, tt.status)
+			}
+			if tt.status != http.StatusBadRequest {
+				continue
+			}
+			headers := []string{"X-Content-Type-Options"}
+			// In htmlOnly mode document headers are kept to HTML errors
+			if htmlOnly == "false" || strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), fiber.MIMETextHTML) {
+				headers = append(headers, "X-Frame-Options")
+			}
+			for _, header := range headers {
+				if resp.Header.Get(header) == "" {
+					t.Errorf("htmlOnly=%s GET /%s: 400 without %s", htmlOnly, tt.query, header)
+				}
+			}
+		}
+	}
+}
+
+func TestCORSRoutes(t *testing.T) {
+	t.Setenv("CORS_ROUTES", "/api")
+	app := setupFiber()
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	tests := []struct {
+		path string
+		cors bool
+	}{
+		{"/api", true},
+		{"/api/tutorials/tui", true},
+		{"/apix", false},
+		{"/tutorial", false},
+		{"/", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set(fiber.HeaderOrigin, "https://notes.example.com")
+		resp, _ := send(t, app, req)
+		if got := resp.Header.Get(fiber.HeaderAccessControlAllowOrigin) != ""; got != tt.cors {
+			t.Errorf("GET %s: CORS headers sent = %v, want %v", tt.path, got, tt.cors)
+		}
+	}
+}
+
+func TestCORSOriginRegex(t *testing.T) {
+	t.Setenv("CORS_ORIGIN_REGEX", `https://.*\.example\.com`)
+	app := setupFiber()
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://notes.example.com", true},
+		{"HTTPS://Notes.Example.COM", true},
+		{"https://example.org", false},
+		{"http://notes.example.com", false},
+		{"https://x.example.com.evil.net", false},
+		{"https://x.example.com:8443", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderOrigin, tt.origin)
+		resp, _ := send(t, app, req)
+		got := resp.Header.Get(fiber.HeaderAccessControlAllowOrigin)
+		// The cors middleware echoes the origin lowercased
+		if tt.allowed && !strings.EqualFold(got, tt.origin) {
+			t.Errorf("Origin %s: Access-Control-Allow-Origin %q, want the origin echoed", tt.origin, got)
+		}
+		if !tt.allowed && got != "" {
+			t.Errorf("Origin %s: Access-Control-Allow-Origin %q, want none", tt.origin, got)
+		}
+	}
+}
+
+func TestCORSOriginMatcher(t *testing.T) {
+	if allow, err := corsOriginMatcher(""); allow != nil || err != nil {
+		t.Errorf("empty pattern: got a matcher or error %v, want neither", err)
+	}
+	if _, err := corsOriginMatcher(`https://(.*\.example\.com`); err == nil {
+		t.Error("invalid pattern: no error, want startup to fail")
+	}
+	allow, err := corsOriginMatcher(`https://a\.example\.com|https://b\.example\.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Alternatives are anchored as a group, not just the first and last
+	for origin, want := range map[string]bool{
+		"https://a.example.com":          true,
+		"https://b.example.com":          true,
+		"https://a.example.com.evil.net": false,
+		"https://evil.net.b.example.com": false,
+	} {
+		if got := allow(origin); got != want {
+			t.Errorf("allow(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestVersionHeader(t *testing.T) {
+	tests := []struct {
+		env    string
+		expose string
+		want   bool
+	}{
+		{"development", "", true},
+		{"production", "", false},
+		{"production", "true", true},
+		{"development", "false", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("ENV", tt.env)
+		t.Setenv("EXPOSE_VERSION_HEADER", tt.expose)
+		app := setupFiber()
+		app.Get("/", func(c *fiber.Ctx) error {
+			return c.SendString("ok")
+		})
+
+		resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, "/", nil))
+		if got := resp.Header.Get("X-Application-Version"); (got != "") != tt.want {
+			t.Errorf("ENV=%s EXPOSE_VERSION_HEADER=%q: X-Application-Version %q, want sent = %v", tt.env, tt.expose, got, tt.want)
+		}
+	}
+}
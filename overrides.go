@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// featureOverrideHeader lets developers toggle features per request, e.g.
+// "X-Feature-Override: nocache, pretty".
+const featureOverrideHeader = "X-Feature-Override"
+
+const featureOverridesKey = "featureOverrides"
+
+// Supported overrides.
+const (
+	// overrideNoCache bypasses the in-memory page caches and sends no-store.
+	overrideNoCache = "nocache"
+	// overridePretty indents JSON response bodies.
+	overridePretty = "pretty"
+	// overrideNoCompress skips response compression.
+	overrideNoCompress = "nocompress"
+)
+
+var knownOverrides = map[string]bool{
+	overrideNoCache:    true,
+	overridePretty:     true,
+	overrideNoCompress: true,
+}
+
+// featureOverridesEnabled allows X-Feature-Override. It is only ever set in
+// development; in production the header is ignored entirely.
+var featureOverridesEnabled bool
+
+// parseFeatureOverrides parses a comma-separated override list, ignoring
+// names it does not know.
+func parseFeatureOverrides(value string) map[string]bool {
+	overrides := make(map[string]bool)
+	for _, name := range splitList(value) {
+		if name = strings.ToLower(name); knownOverrides[name] {
+			overrides[name] = true
+		}
+	}
+	return overrides
+}
+
+// featureOverride reports whether the request asked for the named override.
+func featureOverride(c *fiber.Ctx, name string) bool {
+	if !featureOverridesEnabled {
+		return false
+	}
+	overrides, ok := c.Locals(featureOverridesKey).(map[string]bool)
+	if !ok {
+		overrides = parseFeatureOverrides(c.Get(featureOverrideHeader))
+		c.Locals(featureOverridesKey, overrides)
+	}
+	return overrides[name]
+}
+
+// applyFeatureOverrides applies the overrides that rewrite the response once
+// the handler has run.
+func applyFeatureOverrides(c *fiber.Ctx) error {
+	err := c.Next()
+
+	if featureOverride(c, overrideNoCache) {
+		c.Set(fiber.HeaderCacheControl, "no-store")
+	}
+	if featureOverride(c, overridePretty) && strings.Contains(string(c.Response().Header.ContentType()), "json") {
+		var out bytes.Buffer
+		if json.Indent(&out, c.Response().Body(), "", "  ") == nil {
+			c.Response().SetBodyRaw(out.Bytes())
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFeatureOverridesOnlyInDevelopment(t *testing.T) {
+	t.Cleanup(func() { featureOverridesEnabled = false })
+
+	tests := []struct {
+		env  string
+		want bool
+	}{
+		{"development", true},
+		{"production", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("ENV", tt.env)
+		app := setupFiber()
+		app.Get("/api", func(c *fiber.Ctx) error {
+			return c.JSON(fiber.Map{"ok": true})
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		req.Header.Set(featureOverrideHeader, "Pretty, nocache, unknown")
+		resp, body := send(t, app, req)
+
+		if got := strings.Contains(body, "\n  \"ok\": true"); got != tt.want {
+			t.Errorf("ENV=%s: pretty JSON = %v, want %v: %q", tt.env, got, tt.want, body)
+		}
+		if got := resp.Header.Get(fiber.HeaderCacheControl) == "no-store"; got != tt.want {
+			t.Errorf("ENV=%s: Cache-Control %q, want no-store = %v", tt.env, resp.Header.Get(fiber.HeaderCacheControl), tt.want)
+		}
+	}
+}
+
+func TestParseFeatureOverrides(t *testing.T) {
+	got := parseFeatureOverrides("NoCache, pretty, bogus,,")
+	if len(got) != 2 || !got[overrideNoCache] || !got[overridePretty] {
+		t.Errorf("parseFeatureOverrides = %v, want nocache and pretty only", got)
+	}
+}
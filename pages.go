@@ -0,0 +1,34 @@
+package main
+
+// sitePage is a top-level page, listed in the site map under Group. Pages
+// with a Template are served from it by setupRoutes; the others, such as
+// /tutorial/all, are generated by their own handler.
+type sitePage struct {
+	Path     string
+	Template string
+	Title    string
+	Group    string
+}
+
+// sitePages are the top-level pages in site map order. Tutorial guides are
+// listed separately in tutorialPages.
+var sitePages = []sitePage{
+	{Path: "/", Template: "index.html", Title: "Home", Group: "General"},
+	{Path: "/tutorial", Template: "tutorial.html", Title: "Documentation & Tutorials", Group: "Documentation"},
+	{Path: "/tutorial/all", Title: "Complete Documentation (printable)", Group: "Documentation"},
+}
+
+// pageRoutes maps every template-backed route, from sitePages and
+// tutorialPages, to its template.
+func pageRoutes() map[string]string {
+	routes := make(map[string]string, len(sitePages)+len(tutorialPages))
+	for _, page := range sitePages {
+		if page.Template != "" {
+			routes[page.Path] = page.Template
+		}
+	}
+	for _, page := range tutorialPages {
+		routes[page.Path] = page.Template
+	}
+	return routes
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	dropBlockRe  = regexp.MustCompile(`(?is)<(head|script|style|svg)\b.*?</(head|script|style|svg)>`)
+	commentRe    = regexp.MustCompile(`(?s)<!--.*?-->`)
+	headingTagRe = regexp.MustCompile(`(?i)<h[1-3][^>]*>`)
+	listItemRe   = regexp.MustCompile(`(?i)<li[^>]*>`)
+	blockEndRe   = regexp.MustCompile(`(?i)</(p|div|h[1-6]|summary|tr|pre|section|header|footer)>|<br\s*/?>`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText renders an HTML page as readable plain text: one block per line,
+// headings underlined by blank lines and list items prefixed with "- ".
+func htmlToText(content []byte) string {
+	s := dropBlockRe.ReplaceAllString(string(content), "")
+	s = commentRe.ReplaceAllString(s, "")
+	s = headingTagRe.ReplaceAllString(s, "\n\n")
+	s = listItemRe.ReplaceAllString(s, "\n- ")
+	s = blockEndRe.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(tagRe.ReplaceAllString(s, ""))
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	s = blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
+
+// plaintextCache holds the text rendering of templates, rebuilt only when the
+// template changes.
+type plaintextCache struct {
+	mu      sync.Mutex
+	entries map[string]plaintextEntry
+}
+
+type plaintextEntry struct {
+	etag string
+	text string
+}
+
+func newPlaintextCache() *plaintextCache {
+	return &plaintextCache{entries: make(map[string]plaintextEntry)}
+}
+
+func (p *plaintextCache) get(templates *assetCache, name string) (string, error) {
+	page, err := templates.get(name)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, ok := p.entries[name]; ok && entry.etag == page.etag {
+		return entry.text, nil
+	}
+	text := htmlToText(page.content)
+	p.entries[name] = plaintextEntry{etag: page.etag, text: text}
+	return text, nil
+}
+
+// renderPlainText reads the named template and renders it as plain text.
+func renderPlainText(templates *assetCache, name string) (string, error) {
+	page, err := templates.get(name)
+	if err != nil {
+		return "", err
+	}
+	return htmlToText(page.content), nil
+}
+
+// wantsPlainText reports whether the client asked for text via ?format=txt or
+// an Accept header preferring text/plain over text/html. ?format=html forces
+// the HTML page.
+func wantsPlainText(c *fiber.Ctx) bool {
+	switch c.Query("format") {
+	case "txt":
+		return true
+	case "html":
+		return false
+	}
+	return c.Accepts("text/html", "text/plain") == "text/plain"
+}
+
+// plainTextHandler serves the plain text rendering of the named template to
+// clients that ask for it, and passes everyone else on to the HTML page.
+func plainTextHandler(pages *plaintextCache, templates *assetCache, name string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Vary(fiber.HeaderAccept)
+		if !wantsPlainText(c) {
+			return c.Next()
+		}
+		return servePlainText(c, pages, templates, name)
+	}
+}
+
+// servePlainText sends the plain text rendering of the named template.
+func servePlainText(c *fiber.Ctx, pages *plaintextCache, templates *assetCache, name string) error {
+	get := pages.get
+	if featureOverride(c, overrideNoCache) {
+		get = renderPlainText
+	}
+	text, err := get(templates, name)
+	if err != nil {
+		return sendError(c, fiber.StatusNotFound, "Template not found")
+	}
+	setDownload(c, strings.TrimPrefix(strings.TrimSuffix(name, ".html"), "tutorial-"), "txt")
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	return c.SendString(text)
+}
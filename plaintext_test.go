@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const cliReference = `<!DOCTYPE html><html><head><title>CLI Reference</title><style>h1 { color: red }</style></head>
+<body>
+<h1>CLI &amp; Reference</h1>
+<p>Run   the <code>kg</code>  binary.</p>
+<ul><li>kg add</li><li>kg list</li></ul>
+<script>console.log("hidden")</script>
+</body></html>`
+
+func TestHTMLToText(t *testing.T) {
+	want := "CLI & Reference\n\nRun the kg binary.\n\n- kg add\n- kg list\n"
+	if got := htmlToText([]byte(cliReference)); got != want {
+		t.Errorf("htmlToText = %q, want %q", got, want)
+	}
+}
+
+func TestPlainTextVariant(t *testing.T) {
+	app := newTestApp(t, testRouteConfig(fstest.MapFS{
+		"tutorial-cli-reference.html": {Data: []byte(cliReference)},
+	}, fstest.MapFS{}))
+
+	tests := []struct {
+		query  string
+		accept string
+		text   bool
+	}{
+		{"?format=txt", "", true},
+		{"", "text/plain", true},
+		{"", "text/plain, text/html;q=0.5", true},
+		{"", "text/html", false},
+		{"", "*/*", false},
+		{"?format=html", "text/plain", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/tutorial/cli-reference"+tt.query, nil)
+		if tt.accept != "" {
+			req.Header.Set(fiber.HeaderAccept, tt.accept)
+		}
+		resp, body := send(t, app, req)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s Accept %q: status %d", tt.query, tt.accept, resp.StatusCode)
+		}
+		contentType := resp.Header.Get(fiber.HeaderContentType)
+		if got := contentType == "text/plain; charset=utf-8"; got != tt.text {
+			t.Errorf("%s Accept %q: Content-Type %q, want plain text = %v", tt.query, tt.accept, contentType, tt.text)
+		}
+		if got := strings.Contains(body, "<h1>"); got == tt.text {
+			t.Errorf("%s Accept %q: body %q", tt.query, tt.accept, body)
+		}
+		if !strings.Contains(resp.Header.Get(fiber.HeaderVary), "Accept") {
+			t.Errorf("%s Accept %q: Vary %q, want Accept", tt.query, tt.accept, resp.Header.Get(fiber.HeaderVary))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// queryParams are the query parameters the dashboard understands. Parameters
+// not listed here (e.g. utm_* tracking parameters) are ignored rather than
+// validated.
+var queryParams = []string{"format", "download"}
+
+// queryAllowlist returns the values the route serving path accepts for each
+// of queryParams. A parameter missing from the result is one the route cannot
+// serve at all: ?format=txt is only accepted on the plain text guides,
+// ?format=json only on the JSON endpoints, and ?download only on pages that
+// can be saved.
+func queryAllowlist(path string) map[string][]string {
+	if isJSONRoute(path) {
+		return map[string][]string{"format": {"json"}}
+	}
+
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	for _, page := range tutorialPages {
+		if page.Path == path && page.PlainText {
+			return map[string][]string{
+				"format":   {"html", "txt"},
+				"download": {"true", "false"},
+			}
+		}
+	}
+	if path == "/tutorial/all" {
+		return map[string][]string{
+			"format":   {"html"},
+			"download": {"true", "false"},
+		}
+	}
+	return map[string][]string{"format": {"html"}}
+}
+
+// validateQuery rejects requests where a known query parameter is repeated or
+// carries a value outside the route's allowlist, so unexpected values fail
+// loudly instead of being silently ignored, mis-served or multiplying cache
+// keys. Empty values are treated as absent.
+func validateQuery(c *fiber.Ctx) error {
+	args := c.Context().QueryArgs()
+	allowlist := queryAllowlist(c.Path())
+	for _, name := range queryParams {
+		values := args.PeekMulti(name)
+		if len(values) > 1 {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Query parameter %q must be given at most once", name))
+		}
+		if len(values) == 0 || len(values[0]) == 0 {
+			continue
+		}
+		allowed, ok := allowlist[name]
+		if !ok {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Query parameter %q is not supported by %s", name, c.Path()))
+		}
+		if !slices.Contains(allowed, string(values[0])) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid value %q for query parameter %q", values[0], name))
+		}
+	}
+	return c.Next()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestValidateQuery(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	app.Use(validateQuery)
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		// Missing and empty parameters are fine everywhere
+		{"/", http.StatusOK},
+		{"/?format=", http.StatusOK},
+		{"/?utm_source=newsletter", http.StatusOK},
+		{"/tutorial/cli-reference", http.StatusOK},
+
+		// Valid values for the route
+		{"/?format=html", http.StatusOK},
+		{"/tutorial/cli-reference?format=txt", http.StatusOK},
+		{"/tutorial/cli-reference/?format=txt&download=true", http.StatusOK},
+		{"/tutorial/all?download=true", http.StatusOK},
+		{"/api/tutorials/tui?format=json", http.StatusOK},
+		{"/health?format=json", http.StatusOK},
+
+		// Values the route cannot serve
+		{"/?format=json", http.StatusBadRequest},
+		{"/?format=txt", http.StatusBadRequest},
+		{"/tutorial/tui?format=txt", http.StatusBadRequest},
+		{"/tutorial/all?format=txt", http.StatusBadRequest},
+		{"/api/tutorials/tui?format=html", http.StatusBadRequest},
+		{"/?download=true", http.StatusBadRequest},
+		{"/tutorial/tui?download=true", http.StatusBadRequest},
+
+		// Invalid values
+		{"/?format=xml", http.StatusBadRequest},
+		{"/tutorial/all?download=yes", http.StatusBadRequest},
+
+		// Repeated parameters, even with the same value
+		{"/tutorial/cli-reference?format=txt&format=html", http.StatusBadRequest},
+		{"/?format=html&format=html", http.StatusBadRequest},
+		{"/tutorial/all?download=true&download=false", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, body := send(t, app, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: status %d, want %d: %s", tt.url, resp.StatusCode, tt.status, body)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// inFlight is the number of requests currently being handled by the server.
+var inFlight atomic.Int64
+
+// trackInFlight keeps inFlight up to date for the lifetime of each request.
+func trackInFlight(c *fiber.Ctx) error {
+	inFlight.Add(1)
+	defer inFlight.Add(-1)
+	return c.Next()
+}
+
+// underLoad reports whether the in-flight request count has reached threshold.
+func underLoad(threshold int64) bool {
+	return inFlight.Load() >= threshold
+}
+
+// rateLimitHeaders enables the standard RateLimit-* response headers
+// alongside the X-RateLimit-* ones the limiter middleware always sets.
+var rateLimitHeaders bool
+
+func setupRateLimiting(app *fiber.App) {
+	rateLimitHeaders = getEnvBool("RATE_LIMIT_HEADERS", false)
+	app.Use(trackInFlight)
+
+	// Sustained per-IP limit, always enforced
+	app.Use(newRateLimiter(getEnvInt("RATE_LIMIT", 120), time.Minute, "", nil))
+
+	// Optional short burst limit per IP, enforced on top of the sustained one
+	if burst := getEnvInt("RATE_LIMIT_BURST", 0); burst > 0 {
+		window := getEnvDuration("RATE_LIMIT_BURST_WINDOW", time.Second)
+		if window < time.Second || window%time.Second != 0 {
+			log.Fatalf("Invalid RATE_LIMIT_BURST_WINDOW: %s is not a whole number of seconds", window)
+		}
+		log.Printf("Burst rate limiting enabled: %d req per %s per IP", burst, window)
+		app.Use(newRateLimiter(burst, window, "burst:", nil))
+	}
+
+	// Per-category limits, each with its own budget per IP
+	categories, err := parseRateLimitCategories(getEnv("RATE_LIMIT_CATEGORIES", ""))
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_CATEGORIES: %v", err)
+	}
+	for _, category := range categories {
+		app.Use(newRateLimiter(category.max, time.Minute, category.name+":", func(c *fiber.Ctx) bool {
+			return categoryFor(c.Path(), categories) != category.name
+		}))
+	}
+
+	if !getEnvBool("ADAPTIVE_RATE_LIMIT", false) {
+		return
+	}
+
+	// Adaptive mode: a stricter per-IP limit that only applies while the server
+	// is under load, and is skipped again as soon as the load drops
+	threshold := int64(getEnvInt("ADAPTIVE_LOAD_THRESHOLD", 100))
+	tightMax := getEnvInt("ADAPTIVE_RATE_LIMIT_MAX", 30)
+	log.Printf("Adaptive rate limiting enabled: %d req/min per IP above %d in-flight requests", tightMax, threshold)
+
+	app.Use(newRateLimiter(tightMax, time.Minute, "adaptive:", func(c *fiber.Ctx) bool {
+		return !underLoad(threshold)
+	}))
+}
+
+// rateLimitCategory is a named group of path prefixes sharing one per-IP budget.
+type rateLimitCategory struct {
+	name     string
+	max      int
+	prefixes []string
+}
+
+// parseRateLimitCategories parses "name:max:/prefix|/prefix,..." definitions,
+// e.g. "docs:60:/tutorial|/static,api:30:/api".
+func parseRateLimitCategories(value string) ([]rateLimitCategory, error) {
+	var categories []rateLimitCategory
+	for _, def := range splitList(value) {
+		parts := strings.SplitN(def, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("category %q must be name:max:prefixes", def)
+		}
+		max, err := strconv.Atoi(parts[1])
+		if err != nil || max <= 0 {
+			return nil, fmt.Errorf("category %q has an invalid max %q", parts[0], parts[1])
+		}
+		prefixes := strings.Split(parts[2], "|")
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(prefix, "/") {
+				return nil, fmt.Errorf("category %q has an invalid prefix %q", parts[0], prefix)
+			}
+		}
+		categories = append(categories, rateLimitCategory{name: parts[0], max: max, prefixes: prefixes})
+	}
+	return categories, nil
+}
+
+// categoryFor returns the category whose longest prefix matches path, or ""
+// if the path belongs to no category.
+func categoryFor(path string, categories []rateLimitCategory) string {
+	match, matchLen := "", -1
+	for _, category := range categories {
+		for _, prefix := range category.prefixes {
+			if hasPathPrefix(path, prefix) && len(prefix) > matchLen {
+				match, matchLen = category.name, len(prefix)
+			}
+		}
+	}
+	return match
+}
+
+// newRateLimiter builds a per-IP limiter of max requests per window. keyPrefix
+// separates the budgets of limiters that share the same client.
+func newRateLimiter(max int, window time.Duration, keyPrefix string, next func(c *fiber.Ctx) bool) fiber.Handler {
+	handler := limiter.New(limiter.Config{
+		Next:       next,
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return keyPrefix + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			if rateLimitHeaders {
+				header := &c.Response().Header
+				header.Set("RateLimit-Limit", strconv.Itoa(max))
+				header.Set("RateLimit-Remaining", "0")
+				header.Set("RateLimit-Reset", string(header.Peek(fiber.HeaderRetryAfter)))
+			}
+			return sendError(c, http.StatusTooManyRequests, "Rate limit exceeded")
+		},
+	})
+	if !rateLimitHeaders {
+		return handler
+	}
+	return func(c *fiber.Ctx) error {
+		err := handler(c)
+		setStandardRateLimitHeaders(c)
+		return err
+	}
+}
+
+// setStandardRateLimitHeaders mirrors the limiter's X-RateLimit-* headers
+// into the standard RateLimit-* names. Every limiter in the chain calls it on
+// the way out, so the headers end up describing whichever limiter has the
+// fewest requests remaining.
+func setStandardRateLimitHeaders(c *fiber.Ctx) {
+	header := &c.Response().Header
+	remaining, err := strconv.Atoi(string(header.Peek("X-RateLimit-Remaining")))
+	if err != nil {
+		return
+	}
+	if current, err := strconv.Atoi(string(header.Peek("RateLimit-Remaining"))); err == nil && current <= remaining {
+		return
+	}
+	header.Set("RateLimit-Limit", string(header.Peek("X-RateLimit-Limit")))
+	header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("RateLimit-Reset", string(header.Peek("X-RateLimit-Reset")))
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newLimitedApp returns an app rate limited by setupRateLimiting under env,
+// answering 200 on every path.
+func newLimitedApp(t *testing.T, env map[string]string) *fiber.App {
+	t.Helper()
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	setupRateLimiting(app)
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+// statuses sends n GETs for path and returns their status codes.
+func statuses(t *testing.T, app *fiber.App, path string, n int) []int {
+	t.Helper()
+	codes := make([]int, n)
+	for i := range codes {
+		resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, path, nil))
+		codes[i] = resp.StatusCode
+	}
+	return codes
+}
+
+// countStatus returns how many of codes equal status.
+func countStatus(codes []int, status int) int {
+	n := 0
+	for _, code := range codes {
+		if code == status {
+			n++
+		}
+	}
+	return n
+}
+
+func TestAdaptiveRateLimitTightensUnderLoad(t *testing.T) {
+	app := newLimitedApp(t, map[string]string{
+		"RATE_LIMIT":              "100",
+		"ADAPTIVE_RATE_LIMIT":     "true",
+		"ADAPTIVE_LOAD_THRESHOLD": "5",
+		"ADAPTIVE_RATE_LIMIT_MAX": "2",
+	})
+
+	if codes := statuses(t, app, "/", 5); countStatus(codes, http.StatusOK) != 5 {
+		t.Fatalf("without load: %v, want every request allowed", codes)
+	}
+
+	// Simulate four other requests in flight; with the request itself the
+	// threshold of five is reached
+	inFlight.Add(4)
+	codes := statuses(t, app, "/", 4)
+	inFlight.Add(-4)
+	if got := countStatus(codes, http.StatusOK); got != 2 {
+		t.Errorf("under load: %v, want the first 2 allowed", codes)
+	}
+	if got := countStatus(codes, http.StatusTooManyRequests); got != 2 {
+		t.Errorf("under load: %v, want the rest rejected with 429", codes)
+	}
+
+	if codes := statuses(t, app, "/", 3); countStatus(codes, http.StatusOK) != 3 {
+		t.Errorf("after load drops: %v, want every request allowed again", codes)
+	}
+}
+
+func TestRateLimitCategoriesHaveSeparateBudgets(t *testing.T) {
+	app := newLimitedApp(t, map[string]string{
+		"RATE_LIMIT":            "100",
+		"RATE_LIMIT_CATEGORIES": "docs:2:/tutorial|/static,api:1:/api",
+	})
+
+	tests := []struct {
+		path    string
+		n       int
+		allowed int
+	}{
+		{"/tutorial/tui", 3, 2},
+		// /static shares the docs budget, already spent above
+		{"/static/css/site.css", 1, 0},
+		{"/api/tutorials/tui", 2, 1},
+		{"/", 5, 5},
+	}
+	for _, tt := range tests {
+		codes := statuses(t, app, tt.path, tt.n)
+		if got := countStatus(codes, http.StatusOK); got != tt.allowed {
+			t.Errorf("GET %s x%d: %v, want %d allowed", tt.path, tt.n, codes, tt.allowed)
+		}
+	}
+}
+
+func TestParseRateLimitCategories(t *testing.T) {
+	categories, err := parseRateLimitCategories("docs:60:/tutorial|/static, api:30:/api, tui:5:/tutorial/tui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]string{
+		"/tutorial":      "docs",
+		"/tutorial/self": "docs",
+		"/tutorial/tui":  "tui",
+		"/api/changelog": "api",
+		"/apix":          "",
+		"/":              "",
+	}
+	for path, want := range paths {
+		if got := categoryFor(path, categories); got != want {
+			t.Errorf("categoryFor(%s) = %q, want %q", path, got, want)
+		}
+	}
+
+	for _, invalid := range []string{"docs", "docs:60", "docs:0:/tutorial", "docs:x:/tutorial", "docs:60:tutorial"} {
+		if _, err := parseRateLimitCategories(invalid); err == nil {
+			t.Errorf("parseRateLimitCategories(%q): want an error", invalid)
+		}
+	}
+}
+
+func TestRateLimitHeadersDecrement(t *testing.T) {
+	t.Cleanup(func() { rateLimitHeaders = false })
+	app := newLimitedApp(t, map[string]string{
+		"RATE_LIMIT":         "3",
+		"RATE_LIMIT_HEADERS": "true",
+	})
+
+	for i, want := range []struct {
+		status    int
+		remaining string
+	}{
+		{http.StatusOK, "2"},
+		{http.StatusOK, "1"},
+		{http.StatusOK, "0"},
+		{http.StatusTooManyRequests, "0"},
+	} {
+		resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, "/", nil))
+		if resp.StatusCode != want.status {
+			t.Errorf("request %d: status %d, want %d", i+1, resp.StatusCode, want.status)
+		}
+		if got := resp.Header.Get("RateLimit-Limit"); got != "3" {
+			t.Errorf("request %d: RateLimit-Limit %q, want 3", i+1, got)
+		}
+		if got := resp.Header.Get("RateLimit-Remaining"); got != want.remaining {
+			t.Errorf("request %d: RateLimit-Remaining %q, want %s", i+1, got, want.remaining)
+		}
+		if got := resp.Header.Get("RateLimit-Reset"); got == "" {
+			t.Errorf("request %d: no RateLimit-Reset", i+1)
+		}
+	}
+}
+
+func TestRateLimitHeadersDescribeTightestLimit(t *testing.T) {
+	t.Cleanup(func() { rateLimitHeaders = false })
+	app := newLimitedApp(t, map[string]string{
+		"RATE_LIMIT":         "100",
+		"RATE_LIMIT_BURST":   "5",
+		"RATE_LIMIT_HEADERS": "true",
+	})
+
+	resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := resp.Header.Get("RateLimit-Limit"); got != "5" {
+		t.Errorf("RateLimit-Limit %q, want the burst limit 5", got)
+	}
+	if got := resp.Header.Get("RateLimit-Remaining"); got != "4" {
+		t.Errorf("RateLimit-Remaining %q, want 4", got)
+	}
+}
+
+func TestBurstAndSustainedLimitsApplyIndependently(t *testing.T) {
+	t.Cleanup(func() { rateLimitHeaders = false })
+	tests := []struct {
+		name      string
+		sustained string
+		burst     string
+		allowed   int
+		limit     string
+	}{
+		{"burst reached first", "100", "2", 2, "2"},
+		{"sustained reached first", "3", "100", 3, "3"},
+	}
+	for _, tt := range tests {
+		app := newLimitedApp(t, map[string]string{
+			"RATE_LIMIT":         tt.sustained,
+			"RATE_LIMIT_BURST":   tt.burst,
+			"RATE_LIMIT_HEADERS": "true",
+		})
+		codes := statuses(t, app, "/", tt.allowed)
+		if countStatus(codes, http.StatusOK) != tt.allowed {
+			t.Errorf("%s: %v, want %d allowed", tt.name, codes, tt.allowed)
+		}
+		resp, _ := send(t, app, httptest.NewRequest(http.MethodGet, "/", nil))
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("%s: request %d: status %d, want 429", tt.name, tt.allowed+1, resp.StatusCode)
+		}
+		if got := resp.Header.Get("RateLimit-Limit"); got != tt.limit {
+			t.Errorf("%s: rejected by the limit of %s, want %s", tt.name, got, tt.limit)
+		}
+	}
+}
+
+func TestBurstWindowResetsBeforeSustained(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the burst window to pass")
+	}
+	app := newLimitedApp(t, map[string]string{
+		"RATE_LIMIT":              "4",
+		"RATE_LIMIT_BURST":        "2",
+		"RATE_LIMIT_BURST_WINDOW": "1s",
+	})
+
+	if codes := statuses(t, app, "/", 3); countStatus(codes, http.StatusOK) != 2 {
+		t.Fatalf("first burst: %v, want 2 allowed", codes)
+	}
+
+	// The burst budget is back, but the rejected request still counted
+	// towards the sustained limit, which has one request left
+	time.Sleep(2 * time.Second)
+	if codes := statuses(t, app, "/", 2); codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("after the burst window: %v, want [200 429]", codes)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// upstreamChecker returns nil when target answered with a 2xx status, or the
+// reason it is not usable. It must give up once ctx is done.
+type upstreamChecker func(ctx context.Context, target string) error
+
+// httpUpstreamChecker checks an upstream with a GET request through client.
+func httpUpstreamChecker(client *http.Client) upstreamChecker {
+	return func(ctx context.Context, target string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		// Drain a little of the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("upstream returned %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// parseUpstreamURL validates that raw is an absolute http(s) URL with a host.
+// An empty value means there is no upstream to check.
+func parseUpstreamURL(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("parse %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%q must use http or https", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%q has no host", raw)
+	}
+	return u.String(), nil
+}
+
+// readiness is the upstream check shared by /ready and /status. Without an
+// upstream the dashboard is always ready.
+type readiness struct {
+	upstream string
+	check    upstreamChecker
+	timeout  time.Duration
+}
+
+// probe checks the upstream, giving up after r.timeout.
+func (r readiness) probe(ctx context.Context) error {
+	if r.upstream == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.check(ctx, r.upstream)
+}
+
+// readyHandler is the readiness check. Unlike /health it calls the upstream
+// API and answers 503 with the reason when the upstream cannot be reached
+// within the timeout or does not answer with a 2xx.
+func readyHandler(ready readiness) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		if ready.upstream == "" {
+			return c.JSON(fiber.Map{"status": "ready"})
+		}
+
+		if err := ready.probe(c.UserContext()); err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"status":   "not ready",
+				"upstream": ready.upstream,
+				"error":    err.Error(),
+			})
+		}
+		return c.JSON(fiber.Map{
+			"status":   "ready",
+			"upstream": ready.upstream,
+		})
+	}
+}
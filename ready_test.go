@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReadyHandler(t *testing.T) {
+	reachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer reachable.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name     string
+		upstream string
+		status   int
+		ready    string
+		err      string
+	}{
+		{"no upstream", "", http.StatusOK, "ready", ""},
+		{"reachable", reachable.URL, http.StatusOK, "ready", ""},
+		{"timed out", slow.URL, http.StatusServiceUnavailable, "not ready", "deadline exceeded"},
+		{"500 response", failing.URL, http.StatusServiceUnavailable, "not ready", "500 Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/ready", readyHandler(readiness{
+				upstream: tt.upstream,
+				check:    httpUpstreamChecker(http.DefaultClient),
+				timeout:  50 * time.Millisecond,
+			}))
+
+			start := time.Now()
+			resp, body := send(t, app, httptest.NewRequest(http.MethodGet, "/ready", nil))
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("took %s, want the timeout to cut it short", elapsed)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get(fiber.HeaderCacheControl); got != "no-store" {
+				t.Errorf("Cache-Control %q, want no-store", got)
+			}
+
+			var report struct {
+				Status   string `json:"status"`
+				Upstream string `json:"upstream"`
+				Error    string `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(body), &report); err != nil {
+				t.Fatalf("decode %q: %v", body, err)
+			}
+			if report.Status != tt.ready {
+				t.Errorf("status field %q, want %q", report.Status, tt.ready)
+			}
+			if report.Upstream != tt.upstream {
+				t.Errorf("upstream field %q, want %q", report.Upstream, tt.upstream)
+			}
+			if !strings.Contains(report.Error, tt.err) {
+				t.Errorf("error field %q, want it to contain %q", report.Error, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseUpstreamURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"", "", true},
+		{"https://api.example.com/health", "https://api.example.com/health", true},
+		{"http://localhost:8080", "http://localhost:8080", true},
+		{"ftp://api.example.com", "", false},
+		{"https://", "", false},
+		{"api.example.com", "", false},
+	}
+	for _, tt := range tests {
+		got, err := parseUpstreamURL(tt.raw)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseUpstreamURL(%q) = %q, %v; want %q, ok=%v", tt.raw, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// reloadHook is a named function re-reading some on-disk state.
+type reloadHook struct {
+	name string
+	fn   func() error
+}
+
+// reloader holds the reload hooks registered by setupRoutes and runs them on
+// SIGHUP or POST /admin/reload.
+type reloader struct {
+	mu    sync.Mutex
+	hooks []reloadHook
+
+	// runMu makes reloads run one at a time, whatever triggered them.
+	runMu sync.Mutex
+}
+
+// add registers fn to run on every reload.
+func (r *reloader) add(name string, fn func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, reloadHook{name: name, fn: fn})
+}
+
+// reloadResult is the outcome of one reload hook.
+type reloadResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// run runs every registered hook in registration order. A call made while a
+// reload is running waits for it to finish first.
+func (r *reloader) run() []reloadResult {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	r.mu.Lock()
+	hooks := append([]reloadHook(nil), r.hooks...)
+	r.mu.Unlock()
+
+	results := make([]reloadResult, 0, len(hooks))
+	for _, hook := range hooks {
+		if err := hook.fn(); err != nil {
+			log.Printf("Reload of %s failed: %v", hook.name, err)
+			results = append(results, reloadResult{Name: hook.name, Error: err.Error()})
+			continue
+		}
+		log.Printf("Reloaded %s", hook.name)
+		results = append(results, reloadResult{Name: hook.name, OK: true})
+	}
+	return results
+}
+
+// setupReloadSignal runs the reload hooks on SIGHUP, once per burst of
+// signals no more than RELOAD_DEBOUNCE apart.
+func setupReloadSignal(reloads *reloader) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	go watchReloadSignals(c, max(getEnvDuration("RELOAD_DEBOUNCE", 500*time.Millisecond), 0), reloads)
+}
+
+// watchReloadSignals waits after each signal until debounce passes without
+// another one, then reloads once. A signal arriving during a reload stays
+// buffered in c and triggers one trailing reload afterwards.
+func watchReloadSignals(c <-chan os.Signal, debounce time.Duration, reloads *reloader) {
+	for range c {
+		count := 1
+		timer := time.NewTimer(debounce)
+	collect:
+		for {
+			select {
+			case <-c:
+				count++
+				timer.Reset(debounce)
+			case <-timer.C:
+				break collect
+			}
+		}
+
+		if count > 1 {
+			log.Printf("Received %d SIGHUPs, reloading once...", count)
+		} else {
+			log.Println("Received SIGHUP, reloading...")
+		}
+		reloads.run()
+	}
+}
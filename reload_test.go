@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRapidSIGHUPsReloadOnce(t *testing.T) {
+	reloaded := make(chan struct{}, 10)
+	reloads := &reloader{}
+	reloads.add("count", func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+
+	signals := make(chan os.Signal, 1)
+	go watchReloadSignals(signals, 50*time.Millisecond, reloads)
+	defer close(signals)
+
+	expectReloads := func(want int) {
+		t.Helper()
+		got := 0
+		timeout := time.After(300 * time.Millisecond)
+		for {
+			select {
+			case <-reloaded:
+				got++
+			case <-timeout:
+				if got != want {
+					t.Errorf("%d reloads, want %d", got, want)
+				}
+				return
+			}
+		}
+	}
+
+	for range 5 {
+		signals <- syscall.SIGHUP
+		time.Sleep(5 * time.Millisecond)
+	}
+	expectReloads(1)
+
+	// A later signal, after the burst, reloads again
+	signals <- syscall.SIGHUP
+	expectReloads(1)
+}
+
+func TestReloaderRunsEveryHook(t *testing.T) {
+	reloads := &reloader{}
+	var ran []string
+	reloads.add("changelog", func() error {
+		ran = append(ran, "changelog")
+		return errors.New("unreadable")
+	})
+	reloads.add("templates", func() error {
+		ran = append(ran, "templates")
+		return nil
+	})
+
+	results := reloads.run()
+	if want := []string{"changelog", "templates"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+	want := []reloadResult{{Name: "changelog", Error: "unreadable"}, {Name: "templates", OK: true}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results %+v, want %+v", results, want)
+	}
+}
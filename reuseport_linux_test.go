@@ -0,0 +1,49 @@
+//go:build linux
+
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestListenReusePortSharesAPort(t *testing.T) {
+	old, err := listenReusePort("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := old.Addr().String()
+
+	// Without SO_REUSEPORT the port is taken
+	if ln, err := net.Listen("tcp4", addr); err == nil {
+		ln.Close()
+		t.Fatal("plain listener bound a port already in use")
+	}
+
+	// The new instance binds the same port while the old one is still open
+	next, err := listenReusePort("tcp4", addr)
+	if err != nil {
+		t.Fatalf("second SO_REUSEPORT listener: %v", err)
+	}
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("new instance")
+	})
+	go app.Listener(next)
+	defer app.Shutdown()
+
+	// Once the old instance stops, the new one takes every connection
+	old.Close()
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "new instance" {
+		t.Errorf("body %q, want the new instance to answer", body)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"net"
+)
+
+func listenReusePort(network, addr string) (net.Listener, error) {
+	return nil, errors.New("SO_REUSEPORT listening is only supported on Linux")
+}
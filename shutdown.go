@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// shutdownHook is a named function run once the server has stopped accepting
+// requests, e.g. to sync the request log to disk.
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []shutdownHook
+)
+
+// onShutdown registers fn to run during graceful shutdown. Hooks run in
+// registration order and share the remaining shutdown timeout.
+func onShutdown(name string, fn func(ctx context.Context) error) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, shutdownHook{name: name, fn: fn})
+}
+
+// runShutdownHooks runs every registered hook, giving up on the rest once ctx
+// is done so shutdown never outlives its timeout.
+func runShutdownHooks(ctx context.Context) {
+	shutdownMu.Lock()
+	hooks := append([]shutdownHook(nil), shutdownHooks...)
+	shutdownMu.Unlock()
+
+	for _, hook := range hooks {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Skipping shutdown hook %s: %v", hook.name, err)
+			continue
+		}
+		if err := hook.fn(ctx); err != nil {
+			log.Printf("Shutdown hook %s failed: %v", hook.name, err)
+		}
+	}
+	log.Printf("Flush complete (%d shutdown hooks)", len(hooks))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// withShutdownHooks runs the test with an empty hook registry, restoring the
+// real one afterwards.
+func withShutdownHooks(t *testing.T) {
+	t.Helper()
+	shutdownMu.Lock()
+	saved := shutdownHooks
+	shutdownHooks = nil
+	shutdownMu.Unlock()
+	t.Cleanup(func() {
+		shutdownMu.Lock()
+		shutdownHooks = saved
+		shutdownMu.Unlock()
+	})
+}
+
+func TestRunShutdownHooks(t *testing.T) {
+	withShutdownHooks(t)
+
+	var ran []string
+	hook := func(name string, err error) {
+		onShutdown(name, func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		})
+	}
+	hook("first", nil)
+	hook("failing", errors.New("disk full"))
+	hook("last", nil)
+
+	runShutdownHooks(context.Background())
+
+	// A failing hook does not stop the ones after it
+	if want := []string{"first", "failing", "last"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestRunShutdownHooksStopsAtTimeout(t *testing.T) {
+	withShutdownHooks(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var ran []string
+	onShutdown("slow", func(context.Context) error {
+		ran = append(ran, "slow")
+		cancel()
+		return nil
+	})
+	onShutdown("late", func(context.Context) error {
+		ran = append(ran, "late")
+		return nil
+	})
+
+	runShutdownHooks(ctx)
+
+	if want := []string{"slow"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// siteLink is a page listed in the site map, with its in-page sections.
+type siteLink struct {
+	Path     string
+	Title    string
+	Sections []tutorialSection
+}
+
+// siteGroup is a titled group of links in the site map.
+type siteGroup struct {
+	Name  string
+	Links []siteLink
+}
+
+// siteMap lists every public page, grouped by section: sitePages in their
+// groups, then the guides. Tutorial titles and sections come from the
+// templates, so the map follows tutorialPages.
+func siteMap(templates *assetCache) []siteGroup {
+	var groups []siteGroup
+	for _, page := range sitePages {
+		link := siteLink{Path: page.Path, Title: page.Title}
+		if n := len(groups); n > 0 && groups[n-1].Name == page.Group {
+			groups[n-1].Links = append(groups[n-1].Links, link)
+			continue
+		}
+		groups = append(groups, siteGroup{Name: page.Group, Links: []siteLink{link}})
+	}
+
+	guides := make([]siteLink, 0, len(tutorialPages))
+	for _, page := range tutorialPages {
+		if page.Draft {
+			continue
+		}
+		link := siteLink{Path: page.Path, Title: page.Slug}
+		if file, err := templates.get(page.Template); err == nil {
+			meta := extractTutorialMeta(string(file.content))
+			link.Title = pageTitleRe.ReplaceAllString(meta.Title, "")
+			link.Sections = meta.Sections
+		}
+		guides = append(guides, link)
+	}
+
+	return append(groups, siteGroup{Name: "Guides", Links: guides})
+}
+
+func renderSiteMap(groups []siteGroup) []byte {
+	var body strings.Builder
+	for _, group := range groups {
+		fmt.Fprintf(&body, "        <section class=\"bg-white rounded-3xl p-8 shadow-lg border-2 border-purple-100 mb-6\">\n")
+		fmt.Fprintf(&body, "            <h2 class=\"text-2xl font-bold mb-4 text-gray-900\">%s</h2>\n            <ul class=\"space-y-2\">\n", html.EscapeString(group.Name))
+		for _, link := range group.Links {
+			fmt.Fprintf(&body, "                <li><a href=\"%s\" class=\"text-purple-700 hover:underline font-semibold\">%s</a>",
+				html.EscapeString(link.Path), html.EscapeString(link.Title))
+			if len(link.Sections) > 0 {
+				body.WriteString("\n                    <ul class=\"ml-6 mt-1 space-y-1 text-sm\">\n")
+				for _, section := range link.Sections {
+					fmt.Fprintf(&body, "                        <li><a href=\"%s#%s\" class=\"text-gray-600 hover:text-purple-600\">%s</a></li>\n",
+						html.EscapeString(link.Path), html.EscapeString(section.ID), html.EscapeString(section.Heading))
+				}
+				body.WriteString("                    </ul>\n                ")
+			}
+			body.WriteString("</li>\n")
+		}
+		body.WriteString("            </ul>\n        </section>\n")
+	}
+
+	return []byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Site Map - Knowledge Garden CLI</title>
+    <script nonce="` + cspNoncePlaceholder + `" src="https://cdn.tailwindcss.com"></script>
+</head>
+<body class="bg-gradient-to-br from-purple-50 via-pink-50 to-orange-50">
+    <main class="max-w-4xl mx-auto px-6 py-16">
+        <a href="/" class="text-gray-600 hover:text-purple-600 font-semibold">← Back to Home</a>
+        <h1 class="text-4xl font-extrabold my-6 text-gray-900">🗺️ Site Map</h1>
+` + body.String() + `    </main>
+</body>
+</html>
+`)
+}
+
+// siteMapHandler serves the human-readable site map.
+func siteMapHandler(templates *assetCache) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return sendPage(c, renderSiteMap(siteMap(templates)))
+	}
+}
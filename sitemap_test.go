@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSiteMapListsEveryRoutedPage(t *testing.T) {
+	templates := fstest.MapFS{}
+	for _, tmpl := range pageRoutes() {
+		templates[tmpl] = page("Page", "page")
+	}
+	app := newTestApp(t, testRouteConfig(templates, fstest.MapFS{}))
+
+	resp, body := getPage(t, app, "/sitemap")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /sitemap: status %d", resp.StatusCode)
+	}
+
+	for path := range pageRoutes() {
+		if tutorial, ok := findTutorialByPath(path); ok && tutorial.Draft {
+			continue
+		}
+		if !strings.Contains(body, `href="`+path+`"`) {
+			t.Errorf("site map does not link %s", path)
+		}
+		if resp, _ := getPage(t, app, path); resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: status %d, want 200", path, resp.StatusCode)
+		}
+	}
+	for _, page := range sitePages {
+		if !strings.Contains(body, `href="`+page.Path+`"`) {
+			t.Errorf("site map does not link %s", page.Path)
+		}
+	}
+}
+
+func TestSiteMapGroupsFollowSitePages(t *testing.T) {
+	templates := newAssetCache(fstest.MapFS{}, decodeTemplate, false)
+	templates.preload()
+
+	groups := siteMap(templates)
+	var names []string
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	if got, want := strings.Join(names, ","), "General,Documentation,Guides"; got != want {
+		t.Errorf("groups %s, want %s", got, want)
+	}
+	if got := len(groups[1].Links); got != 2 {
+		t.Errorf("Documentation has %d links, want 2", got)
+	}
+}
+
+func findTutorialByPath(path string) (tutorialPage, bool) {
+	for _, page := range tutorialPages {
+		if page.Path == path {
+			return page, true
+		}
+	}
+	return tutorialPage{}, false
+}
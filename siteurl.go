@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// siteURL is the public base URL of the dashboard, set from SITE_URL at
+// startup. When nil, absoluteURL falls back to root-relative paths.
+var siteURL *url.URL
+
+// parseSiteURL validates that raw is an absolute http(s) URL with a host and
+// no query or fragment, and strips any trailing slash from its path.
+func parseSiteURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%q must use http or https", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%q has no host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("%q must not contain a query or fragment", raw)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u, nil
+}
+
+// configureSiteURL sets siteURL from raw; an empty value leaves it unset.
+func configureSiteURL(raw string) error {
+	if raw == "" {
+		siteURL = nil
+		return nil
+	}
+	u, err := parseSiteURL(raw)
+	if err != nil {
+		return err
+	}
+	siteURL = u
+	return nil
+}
+
+// absoluteURL resolves a root-relative path such as "/tutorial/tui" against
+// SITE_URL. Without SITE_URL it returns path unchanged.
+func absoluteURL(path string) string {
+	if siteURL == nil {
+		return path
+	}
+	return siteURL.String() + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestParseSiteURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"https://notes.example.com", "https://notes.example.com", true},
+		{"https://notes.example.com/", "https://notes.example.com", true},
+		{"http://localhost:3000/docs/", "http://localhost:3000/docs", true},
+		{"notes.example.com", "", false},
+		{"ftp://notes.example.com", "", false},
+		{"https://", "", false},
+		{"https://notes.example.com/?ref=1", "", false},
+		{"https://notes.example.com/#top", "", false},
+		{"https://notes example.com", "", false},
+	}
+	for _, tt := range tests {
+		u, err := parseSiteURL(tt.raw)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseSiteURL(%q): error %v, want ok=%v", tt.raw, err, tt.ok)
+			continue
+		}
+		if err == nil && u.String() != tt.want {
+			t.Errorf("parseSiteURL(%q) = %s, want %s", tt.raw, u, tt.want)
+		}
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	t.Cleanup(func() { siteURL = nil })
+
+	if got := absoluteURL("/tutorial/tui"); got != "/tutorial/tui" {
+		t.Errorf("without SITE_URL: %s, want /tutorial/tui", got)
+	}
+
+	if err := configureSiteURL("https://notes.example.com/docs/"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := absoluteURL("/tutorial/tui"), "https://notes.example.com/docs/tutorial/tui"; got != want {
+		t.Errorf("with SITE_URL: %s, want %s", got, want)
+	}
+
+	if err := configureSiteURL("not a url"); err == nil {
+		t.Error("configureSiteURL accepted an invalid URL")
+	}
+	if err := configureSiteURL(""); err != nil || siteURL != nil {
+		t.Errorf("empty SITE_URL: %v, siteURL %v; want it unset", err, siteURL)
+	}
+}
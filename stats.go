@@ -0,0 +1,205 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notFoundRoute is the bucket used for requests that did not match a page.
+const notFoundRoute = "(not found)"
+
+// routeStats counts hits per route and keeps a latency histogram for each.
+// Each route gets its own atomic counters, so concurrent requests never
+// contend on a lock once the route is known.
+type routeStats struct {
+	counters sync.Map // route path -> *routeCounter
+
+	// latencyBuckets is the number of histogram buckets per doubling of
+	// latency, set from STATS_LATENCY_BUCKETS. Zero disables latency
+	// tracking.
+	latencyBuckets int
+}
+
+var hitStats = &routeStats{latencyBuckets: 8}
+
+type routeCounter struct {
+	hits    atomic.Uint64
+	latency *latencyHistogram
+}
+
+func (s *routeStats) hit(route string, elapsed time.Duration) {
+	counter, ok := s.counters.Load(route)
+	if !ok {
+		counter, _ = s.counters.LoadOrStore(route, &routeCounter{
+			latency: newLatencyHistogram(s.latencyBuckets),
+		})
+	}
+	rc := counter.(*routeCounter)
+	rc.hits.Add(1)
+	if rc.latency != nil {
+		rc.latency.observe(elapsed)
+	}
+}
+
+// snapshot returns the per-route counts and latency percentiles, and the
+// total count.
+func (s *routeStats) snapshot() (map[string]routeHits, uint64) {
+	routes := make(map[string]routeHits)
+	var total uint64
+	s.counters.Range(func(key, value any) bool {
+		rc := value.(*routeCounter)
+		route := routeHits{Route: key.(string), Hits: rc.hits.Load()}
+		if rc.latency != nil {
+			route.Latency = rc.latency.percentiles()
+		}
+		routes[route.Route] = route
+		total += route.Hits
+		return true
+	})
+	return routes, total
+}
+
+// countHits records a hit and its latency against the matched route once the
+// request is handled. It is only installed while ENABLE_STATS=true.
+func countHits(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	route := c.Route().Path
+	if c.Response().StatusCode() == fiber.StatusNotFound {
+		route = notFoundRoute
+	}
+	hitStats.hit(route, time.Since(start))
+
+	return err
+}
+
+// latencyHistogram is a streaming latency estimator: a histogram of atomic
+// counters over buckets that grow geometrically, each doubling of latency
+// split into the same number of buckets. Recording a latency is a single
+// atomic add, memory is fixed however many requests a route sees, and every
+// percentile is within half a bucket, 1/(2*buckets) relative, of the exact
+// value. It covers every latency since startup.
+type latencyHistogram struct {
+	shift   uint // log2 of the buckets per doubling
+	buckets []atomic.Uint64
+}
+
+// newLatencyHistogram returns a histogram with perDoubling buckets for each
+// doubling of latency, or nil when perDoubling is not positive. perDoubling
+// must be a power of two.
+func newLatencyHistogram(perDoubling int) *latencyHistogram {
+	if perDoubling <= 0 {
+		return nil
+	}
+	shift := uint(bits.TrailingZeros(uint(perDoubling)))
+	return &latencyHistogram{
+		shift:   shift,
+		buckets: make([]atomic.Uint64, (64-int(shift))<<shift),
+	}
+}
+
+// bucket returns the index of the bucket holding d. Below 1<<shift ns each
+// nanosecond has its own bucket; above it the index is the position of the
+// top bit followed by the next shift bits.
+func (h *latencyHistogram) bucket(d time.Duration) int {
+	v := uint64(max(d, 0))
+	if v < 1<<h.shift {
+		return int(v)
+	}
+	exp := uint(bits.Len64(v)-1) - h.shift
+	return int(exp+1)<<h.shift + int(v>>exp&(1<<h.shift-1))
+}
+
+// bounds returns the range [lower, upper) of latencies in bucket i.
+func (h *latencyHistogram) bounds(i int) (lower, upper time.Duration) {
+	n := 1 << h.shift
+	if i < n {
+		return time.Duration(i), time.Duration(i + 1)
+	}
+	exp := uint(i>>h.shift - 1)
+	m := uint64(n + i&(n-1))
+	lower, upper = time.Duration(m<<exp), time.Duration((m+1)<<exp)
+	if upper < lower {
+		// The top bucket runs up to the longest Duration
+		upper = math.MaxInt64
+	}
+	return lower, upper
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	h.buckets[h.bucket(d)].Add(1)
+}
+
+// latencyPercentiles are reported in milliseconds.
+type latencyPercentiles struct {
+	Samples int     `json:"samples"`
+	P50     float64 `json:"p50_ms"`
+	P90     float64 `json:"p90_ms"`
+	P99     float64 `json:"p99_ms"`
+}
+
+// percentiles returns the nearest-rank p50, p90 and p99, each taken as the
+// midpoint of the bucket holding it, or nil before the first sample.
+func (h *latencyHistogram) percentiles() *latencyPercentiles {
+	counts := make([]uint64, len(h.buckets))
+	var n uint64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		n += counts[i]
+	}
+	if n == 0 {
+		return nil
+	}
+
+	rank := func(q float64) float64 {
+		target := max(uint64(math.Ceil(q*float64(n))), 1)
+		var seen uint64
+		for i, count := range counts {
+			if seen += count; seen >= target {
+				lower, upper := h.bounds(i)
+				return float64(lower+upper) / 2 / float64(time.Millisecond)
+			}
+		}
+		return 0
+	}
+	return &latencyPercentiles{
+		Samples: int(n),
+		P50:     rank(0.50),
+		P90:     rank(0.90),
+		P99:     rank(0.99),
+	}
+}
+
+type routeHits struct {
+	Route   string              `json:"route"`
+	Hits    uint64              `json:"hits"`
+	Latency *latencyPercentiles `json:"latency,omitempty"`
+}
+
+// ranked returns the per-route counts, busiest route first, and the total.
+func (s *routeStats) ranked() ([]routeHits, uint64) {
+	routes, total := s.snapshot()
+	list := make([]routeHits, 0, len(routes))
+	for _, route := range routes {
+		list = append(list, route)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Hits > list[j].Hits
+	})
+	return list, total
+}
+
+func statsHandler(c *fiber.Ctx) error {
+	list, total := hitStats.ranked()
+	return c.JSON(fiber.Map{
+		"total":  total,
+		"routes": list,
+	})
+}
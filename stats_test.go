@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mutexStats is the single-lock map routeStats replaced, kept as a baseline
+// for the benchmarks.
+type mutexStats struct {
+	mu   sync.Mutex
+	hits map[string]uint64
+}
+
+func (s *mutexStats) hit(route string) {
+	s.mu.Lock()
+	s.hits[route]++
+	s.mu.Unlock()
+}
+
+var benchRoutes = func() []string {
+	routes := make([]string, 8)
+	for i := range routes {
+		routes[i] = "/route/" + strconv.Itoa(i)
+	}
+	return routes
+}()
+
+func BenchmarkRouteStatsHit(b *testing.B) {
+	for _, buckets := range []int{0, 8} {
+		b.Run("buckets="+strconv.Itoa(buckets), func(b *testing.B) {
+			stats := &routeStats{latencyBuckets: buckets}
+			var next atomic.Uint64
+			b.RunParallel(func(pb *testing.PB) {
+				route := benchRoutes[next.Add(1)%uint64(len(benchRoutes))]
+				for pb.Next() {
+					stats.hit(route, time.Millisecond)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkMutexStatsHit(b *testing.B) {
+	stats := &mutexStats{hits: make(map[string]uint64)}
+	var next atomic.Uint64
+	b.RunParallel(func(pb *testing.PB) {
+		route := benchRoutes[next.Add(1)%uint64(len(benchRoutes))]
+		for pb.Next() {
+			stats.hit(route)
+		}
+	})
+}
+
+func TestRouteStatsHitConcurrent(t *testing.T) {
+	stats := &routeStats{}
+	var wg sync.WaitGroup
+	for _, route := range benchRoutes {
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 250 {
+					stats.hit(route, time.Millisecond)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	routes, total := stats.snapshot()
+	if want := uint64(len(benchRoutes) * 1000); total != want {
+		t.Errorf("total %d, want %d", total, want)
+	}
+	for _, route := range benchRoutes {
+		if got := routes[route].Hits; got != 1000 {
+			t.Errorf("%s: %d hits, want 1000", route, got)
+		}
+	}
+}
+
+func TestLatencyHistogramBuckets(t *testing.T) {
+	for _, perDoubling := range []int{1, 8, 64} {
+		h := newLatencyHistogram(perDoubling)
+		prev := -1
+		for _, d := range []time.Duration{
+			0, 1, 7, 8, 9, 63, 64, 65, 1000,
+			time.Microsecond, 999 * time.Microsecond, time.Millisecond,
+			1234567 * time.Nanosecond, time.Second, time.Hour, 1 << 62,
+		} {
+			i := h.bucket(d)
+			if i < prev || i >= len(h.buckets) {
+				t.Errorf("%d buckets: %v in bucket %d after %d of %d", perDoubling, d, i, prev, len(h.buckets))
+			}
+			prev = i
+			lower, upper := h.bounds(i)
+			if d < lower || d >= upper {
+				t.Errorf("%d buckets: %v in bucket %d covering [%v, %v)", perDoubling, d, i, lower, upper)
+			}
+			// Each bucket spans at most 1/perDoubling of its lower bound
+			if width := upper - lower; width > 1 && float64(width) > float64(lower)/float64(perDoubling) {
+				t.Errorf("%d buckets: bucket %d [%v, %v) is wider than 1/%d", perDoubling, i, lower, upper, perDoubling)
+			}
+		}
+	}
+	if newLatencyHistogram(0) != nil {
+		t.Error("zero buckets should disable latency tracking")
+	}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	h := newLatencyHistogram(8)
+	if p := h.percentiles(); p != nil {
+		t.Errorf("empty histogram: %+v, want nil", p)
+	}
+
+	// 1ms..100ms in shuffled order, then 100 more at 1s
+	for i := range 100 {
+		h.observe(time.Duration((i*37)%100+1) * time.Millisecond)
+	}
+	assertPercentiles(t, h.percentiles(), latencyPercentiles{Samples: 100, P50: 50, P90: 90, P99: 99})
+	for range 100 {
+		h.observe(time.Second)
+	}
+	assertPercentiles(t, h.percentiles(), latencyPercentiles{Samples: 200, P50: 100, P90: 1000, P99: 1000})
+}
+
+// assertPercentiles checks got against want to within the 1/16 relative error
+// of a histogram with 8 buckets per doubling.
+func assertPercentiles(t *testing.T, got *latencyPercentiles, want latencyPercentiles) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("percentiles nil, want %+v", want)
+	}
+	near := func(got, want float64) bool {
+		return math.Abs(got-want) <= want/16
+	}
+	if got.Samples != want.Samples || !near(got.P50, want.P50) || !near(got.P90, want.P90) || !near(got.P99, want.P99) {
+		t.Errorf("percentiles %+v, want about %+v", *got, want)
+	}
+}
+
+func TestStatsHandlerReportsLatencies(t *testing.T) {
+	prev := hitStats
+	hitStats = &routeStats{latencyBuckets: 8}
+	t.Cleanup(func() { hitStats = prev })
+	for _, ms := range []int{1, 2, 3, 4} {
+		hitStats.hit("/tutorial", time.Duration(ms)*time.Millisecond)
+	}
+
+	app := fiber.New()
+	app.Get("/stats", statsHandler)
+	_, body := send(t, app, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	var stats struct {
+		Total  uint64      `json:"total"`
+		Routes []routeHits `json:"routes"`
+	}
+	if err := json.Unmarshal([]byte(body), &stats); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	if stats.Total != 4 || len(stats.Routes) != 1 || stats.Routes[0].Latency == nil {
+		t.Fatalf("stats %s", body)
+	}
+	assertPercentiles(t, stats.Routes[0].Latency, latencyPercentiles{Samples: 4, P50: 2, P90: 4, P99: 4})
+}
+
+func TestCountHitsOnlyWithStatsEnabled(t *testing.T) {
+	for _, enabled := range []string{"false", "true"} {
+		prev := hitStats
+		hitStats = &routeStats{latencyBuckets: 8}
+		t.Setenv("ENABLE_STATS", enabled)
+		app := setupFiber()
+		app.Get("/", func(c *fiber.Ctx) error {
+			return c.SendString("ok")
+		})
+		send(t, app, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		_, total := hitStats.snapshot()
+		hitStats = prev
+		if want := map[string]uint64{"false": 0, "true": 1}[enabled]; total != want {
+			t.Errorf("ENABLE_STATS=%s: %d hits recorded, want %d", enabled, total, want)
+		}
+	}
+}
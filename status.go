@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// startedAt is when the process started, for the uptime on /status.
+var startedAt = time.Now()
+
+// statusReport holds the live values shown on /status.
+type statusReport struct {
+	Version   string
+	StartedAt time.Time
+	Uptime    time.Duration
+	Upstream  string
+	ReadyErr  error
+	InFlight  int64
+	// Request counts are only kept while ENABLE_STATS=true
+	StatsEnabled bool
+	Total        uint64
+	Routes       []routeHits
+}
+
+// renderStatus renders report as a self-contained HTML page. It uses inline
+// styles only, so it works without any external assets.
+func renderStatus(report statusReport) []byte {
+	readiness := `<span class="ok">Ready</span>`
+	if report.ReadyErr != nil {
+		readiness = `<span class="bad">Not ready</span> ` + html.EscapeString(report.ReadyErr.Error())
+	}
+	upstream := "none configured"
+	if report.Upstream != "" {
+		upstream = html.EscapeString(report.Upstream)
+	}
+
+	total := "not counted"
+	var routes strings.Builder
+	switch {
+	case !report.StatsEnabled:
+		routes.WriteString("            <tr><td colspan=\"2\">Set ENABLE_STATS=true to count requests</td></tr>\n")
+	case len(report.Routes) == 0:
+		total = "0"
+		routes.WriteString("            <tr><td colspan=\"2\">No requests yet</td></tr>\n")
+	default:
+		total = strconv.FormatUint(report.Total, 10)
+		for _, route := range report.Routes {
+			fmt.Fprintf(&routes, "            <tr><td>%s</td><td>%d</td></tr>\n", html.EscapeString(route.Route), route.Hits)
+		}
+	}
+
+	return []byte(fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Status - Knowledge Garden CLI</title>
+    <style>
+        body { font-family: system-ui, sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; color: #1f2937; }
+        table { border-collapse: collapse; width: 100%%; margin-bottom: 1.5rem; }
+        th, td { text-align: left; padding: 0.35rem 0.5rem; border-bottom: 1px solid #e5e7eb; }
+        th { width: 40%%; color: #6b7280; font-weight: 600; }
+        .ok { color: #047857; font-weight: 700; }
+        .bad { color: #b91c1c; font-weight: 700; }
+    </style>
+</head>
+<body>
+    <h1>%s</h1>
+    <table>
+        <tr><th>Version</th><td>%s</td></tr>
+        <tr><th>Started</th><td>%s</td></tr>
+        <tr><th>Uptime</th><td>%s</td></tr>
+        <tr><th>Readiness</th><td>%s</td></tr>
+        <tr><th>Upstream</th><td>%s</td></tr>
+        <tr><th>In-flight requests</th><td>%d</td></tr>
+        <tr><th>Total requests</th><td>%s</td></tr>
+    </table>
+    <table>
+        <thead><tr><th>Route</th><th>Requests</th></tr></thead>
+        <tbody>
+%s        </tbody>
+    </table>
+</body>
+</html>
+`,
+		html.EscapeString(appName),
+		html.EscapeString(report.Version),
+		report.StartedAt.UTC().Format(time.RFC1123),
+		report.Uptime.Round(time.Second),
+		readiness,
+		upstream,
+		report.InFlight,
+		total,
+		routes.String(),
+	))
+}
+
+// statusHandler serves the operator status page with live values. Request
+// counts are shown when statsEnabled.
+func statusHandler(ready readiness, statsEnabled bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		routes, total := hitStats.ranked()
+		report := statusReport{
+			Version:   version,
+			StartedAt: startedAt,
+			Uptime:    time.Since(startedAt),
+			Upstream:  ready.upstream,
+			ReadyErr:  ready.probe(c.UserContext()),
+			InFlight:  inFlight.Load(),
+
+			StatsEnabled: statsEnabled,
+			Total:        total,
+			Routes:       routes,
+		}
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return sendPage(c, renderStatus(report))
+	}
+}
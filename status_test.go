@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStatusPageShowsLiveValues(t *testing.T) {
+	prev := hitStats
+	hitStats = &routeStats{}
+	t.Cleanup(func() { hitStats = prev })
+	for range 3 {
+		hitStats.hit("/tutorial", time.Millisecond)
+	}
+	hitStats.hit("/", time.Millisecond)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	cfg := testRouteConfig(fstest.MapFS{}, fstest.MapFS{})
+	cfg.statusEnabled = true
+	cfg.statusToken = "secret"
+	cfg.statsEnabled = true
+	cfg.ready = readiness{upstream: upstream.URL, check: httpUpstreamChecker(http.DefaultClient), timeout: time.Second}
+	app := newTestApp(t, cfg)
+
+	if resp, _ := getPage(t, app, "/status"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want 401", resp.StatusCode)
+	}
+
+	inFlight.Add(2)
+	resp, body := getPage(t, app, "/status?token=secret")
+	inFlight.Add(-2)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("with token: status %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control %q, want no-store", got)
+	}
+	for _, want := range []string{
+		"<tr><th>Version</th><td>" + version + "</td></tr>",
+		"<tr><th>Upstream</th><td>" + upstream.URL + "</td></tr>",
+		`<span class="bad">Not ready</span>`,
+		"<tr><th>In-flight requests</th><td>2</td></tr>",
+		"<tr><th>Total requests</th><td>4</td></tr>",
+		"<tr><td>/tutorial</td><td>3</td></tr>",
+		"<tr><td>/</td><td>1</td></tr>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("status page does not contain %s", want)
+		}
+	}
+	if strings.Index(body, "<td>/tutorial</td>") > strings.Index(body, "<td>/</td>") {
+		t.Error("routes are not ranked by hits")
+	}
+}
+
+func TestStatusPageWithoutStats(t *testing.T) {
+	cfg := testRouteConfig(fstest.MapFS{}, fstest.MapFS{})
+	cfg.statusEnabled = true
+	app := newTestApp(t, cfg)
+
+	_, body := getPage(t, app, "/status")
+	for _, want := range []string{
+		"<tr><th>Total requests</th><td>not counted</td></tr>",
+		"Set ENABLE_STATS=true to count requests",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("status page does not contain %s", want)
+		}
+	}
+}
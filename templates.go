@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// registerTemplateRoutes serves each route from its template in fsys, given
+// as a map of URL path to template name. Several routes may share a template.
+// A name that is not a plain path inside the templates root (e.g. one
+// containing "..") is never read: its route logs a warning at startup and
+// serves the 404 page, as does a route whose template is missing.
+//
+// If fsys is already a template cache, the routes serve from it and share it
+// with its other users; otherwise fsys is read into a new cache. The cache in
+// use is returned.
+func registerTemplateRoutes(app *fiber.App, fsys fs.FS, routes map[string]string) *assetCache {
+	templates, ok := fsys.(*assetCache)
+	if !ok {
+		templates = newAssetCache(fsys, decodeTemplate, false)
+		templates.preload()
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(routes)) {
+		name := routes[path]
+		if !validTemplateName(name) {
+			log.Printf("Warning: route %s has invalid template name %q, serving 404", path, name)
+			app.Get(path, func(c *fiber.Ctx) error {
+				return sendNotFound(c, templates)
+			})
+			continue
+		}
+		app.Get(path, func(c *fiber.Ctx) error {
+			return serveTemplate(c, templates, name)
+		})
+	}
+	return templates
+}
+
+// validTemplateName reports whether name stays inside the templates root.
+func validTemplateName(name string) bool {
+	return fs.ValidPath(name) && !strings.Contains(name, `\`)
+}
+
+// serveTemplate sends the named template as UTF-8 HTML. Pages without
+// per-request rewrites carry ETag and Last-Modified so browsers can cache them.
+// A missing template serves the 404 page.
+func serveTemplate(c *fiber.Ctx, templates *assetCache, name string) error {
+	page, err := templates.get(name)
+	if err != nil {
+		log.Printf("Error reading template %s: %v", name, err)
+		return sendNotFound(c, templates)
+	}
+	if !hasPageRewrites(c) && notModified(c, page) {
+		return nil
+	}
+	return sendPage(c, page.content)
+}
+
+// catchAllKey is the Locals key set on requests that reached the catch-all
+// 404 handler, i.e. matched no route.
+const catchAllKey = "catchAll"
+
+// notFoundHandler is the catch-all registered last, answering every request
+// no route handled with sendNotFound.
+func notFoundHandler(templates *assetCache) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(catchAllKey, true)
+		return sendNotFound(c, templates)
+	}
+}
+
+// sendNotFound answers with the 404.html page, or a JSON error for clients
+// that prefer JSON or when the page itself is missing.
+func sendNotFound(c *fiber.Ctx, templates *assetCache) error {
+	if preferredErrorFormat(c) == "json" {
+		return sendError(c, fiber.StatusNotFound, "Page not found")
+	}
+	page, err := templates.get("404.html")
+	if err != nil {
+		return sendError(c, fiber.StatusNotFound, "Page not found")
+	}
+	c.Vary(fiber.HeaderAccept)
+	c.Status(fiber.StatusNotFound)
+	return sendPage(c, page.content)
+}
+
+// hasPageRewrites reports whether sendPage will change the page for this
+// request, which makes its body differ from the cached template.
+func hasPageRewrites(c *fiber.Ctx) bool {
+	_, nonce := c.Locals(cspNonceKey).(string)
+	reload, _ := c.Locals(liveReloadKey).(bool)
+	return nonce || reload
+}
+
+// sendPage sends an HTML page after the per-request rewrites (live reload
+// script, CSP nonce) have been applied.
+func sendPage(c *fiber.Ctx, content []byte) error {
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	return c.Send(fillCSPNonce(c, injectLiveReload(c, content)))
+}
+
+// setDownload marks the response as an attachment when the request has
+// ?download=true, so browsers save it as knowledge-garden-<name>-<version>.<ext>
+// instead of displaying it.
+func setDownload(c *fiber.Ctx, name, ext string) {
+	if c.Query("download") != "true" {
+		return
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="knowledge-garden-%s-%s.%s"`, name, version, ext))
+}
+
+// decodeTemplate strips a UTF-8 byte order mark and converts UTF-16 templates
+// to UTF-8, so the body always matches the charset=utf-8 we advertise.
+func decodeTemplate(name string, content []byte) []byte {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		content = content[len(bomUTF8):]
+	case bytes.HasPrefix(content, bomUTF16LE):
+		log.Printf("Warning: template %s is UTF-16LE encoded, converting to UTF-8", name)
+		return decodeUTF16(content[len(bomUTF16LE):], binary.LittleEndian)
+	case bytes.HasPrefix(content, bomUTF16BE):
+		log.Printf("Warning: template %s is UTF-16BE encoded, converting to UTF-8", name)
+		return decodeUTF16(content[len(bomUTF16BE):], binary.BigEndian)
+	}
+
+	if !utf8.Valid(content) {
+		log.Printf("Warning: template %s is not valid UTF-8", name)
+	}
+	return content
+}
+
+func decodeUTF16(content []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16(content[i*2:])
+	}
+	return []byte(string(utf16.Decode(units)))
+}
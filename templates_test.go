@@ -0,0 +1,180 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getPage requests path as a browser would.
+func getPage(t *testing.T, app *fiber.App, path string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(fiber.HeaderAccept, "text/html")
+	return send(t, app, req)
+}
+
+func TestRegisterTemplateRoutes(t *testing.T) {
+	templates := fstest.MapFS{
+		"index.html":  page("Home", "home page"),
+		"shared.html": page("Shared", "shared page"),
+		"404.html":    page("Not Found", "custom not found"),
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: customErrorHandler})
+	registerTemplateRoutes(app, templates, map[string]string{
+		"/":        "index.html",
+		"/one":     "shared.html",
+		"/two":     "shared.html",
+		"/missing": "missing.html",
+		"/escape":  "../index.html",
+		"/abs":     "/index.html",
+		"/windows": `..\index.html`,
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/", http.StatusOK, "home page"},
+		{"/one", http.StatusOK, "shared page"},
+		{"/two", http.StatusOK, "shared page"},
+		{"/missing", http.StatusNotFound, "custom not found"},
+		{"/escape", http.StatusNotFound, "custom not found"},
+		{"/abs", http.StatusNotFound, "custom not found"},
+		{"/windows", http.StatusNotFound, "custom not found"},
+	}
+	for _, tt := range tests {
+		resp, body := getPage(t, app, tt.path)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if !strings.Contains(body, tt.body) {
+			t.Errorf("GET %s: body %q does not contain %q", tt.path, body, tt.body)
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); got != "text/html; charset=utf-8" {
+			t.Errorf("GET %s: Content-Type %q, want text/html; charset=utf-8", tt.path, got)
+		}
+	}
+}
+
+func TestRegisterTemplateRoutesSharedTemplateHasOneETag(t *testing.T) {
+	app := fiber.New()
+	registerTemplateRoutes(app, fstest.MapFS{"shared.html": page("Shared", "shared")}, map[string]string{
+		"/one": "shared.html",
+		"/two": "shared.html",
+	})
+
+	one, _ := getPage(t, app, "/one")
+	two, _ := getPage(t, app, "/two")
+	if one.Header.Get(fiber.HeaderETag) == "" || one.Header.Get(fiber.HeaderETag) != two.Header.Get(fiber.HeaderETag) {
+		t.Errorf("ETags %q and %q, want the same non-empty ETag", one.Header.Get(fiber.HeaderETag), two.Header.Get(fiber.HeaderETag))
+	}
+}
+
+func TestRegisterTemplateRoutesCannotEscapeRoot(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "templates")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.html"), []byte("top secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	registerTemplateRoutes(app, os.DirFS(dir), map[string]string{
+		"/leak": "../secret.html",
+	})
+
+	resp, body := getPage(t, app, "/leak")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status %d, want 404", resp.StatusCode)
+	}
+	if strings.Contains(body, "top secret") {
+		t.Errorf("body leaked the file outside the templates root: %q", body)
+	}
+}
+
+func TestRegisterTemplateRoutesSharesACache(t *testing.T) {
+	templates := newAssetCache(fstest.MapFS{"index.html": page("Home", "home")}, decodeTemplate, false)
+	templates.preload()
+
+	if got := registerTemplateRoutes(fiber.New(), templates, map[string]string{"/": "index.html"}); got != templates {
+		t.Error("registerTemplateRoutes did not reuse the template cache it was given")
+	}
+}
+
+func TestDecodeTemplate(t *testing.T) {
+	utf16le := []byte{0xFF, 0xFE}
+	utf16be := []byte{0xFE, 0xFF}
+	for _, r := range "héllo" {
+		utf16le = append(utf16le, byte(r), byte(r>>8))
+		utf16be = append(utf16be, byte(r>>8), byte(r))
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"plain", []byte("héllo"), "héllo"},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "héllo"...), "héllo"},
+		{"utf-16le", utf16le, "héllo"},
+		{"utf-16be", utf16be, "héllo"},
+	}
+	for _, tt := range tests {
+		if got := string(decodeTemplate(tt.name, tt.content)); got != tt.want {
+			t.Errorf("%s: decoded %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateWithBOMServedAsUTF8(t *testing.T) {
+	app := fiber.New()
+	registerTemplateRoutes(app, fstest.MapFS{
+		"index.html": {Data: append([]byte{0xEF, 0xBB, 0xBF}, "<title>Héllo</title>"...)},
+	}, map[string]string{"/": "index.html"})
+
+	resp, body := getPage(t, app, "/")
+	if got := resp.Header.Get(fiber.HeaderContentType); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type %q, want text/html; charset=utf-8", got)
+	}
+	if body != "<title>Héllo</title>" {
+		t.Errorf("body %q, want the template without its byte order mark", body)
+	}
+}
+
+func TestDownloadContentDisposition(t *testing.T) {
+	templates := fstest.MapFS{}
+	for _, page := range tutorialPages {
+		templates[page.Template] = guide(page.Slug)
+	}
+	app := newTestApp(t, testRouteConfig(templates, fstest.MapFS{}))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tutorial/cli-reference?format=txt&download=true", `attachment; filename="knowledge-garden-cli-reference-` + version + `.txt"`},
+		{"/tutorial/all?download=true", `attachment; filename="knowledge-garden-tutorials-` + version + `.html"`},
+		{"/tutorial/cli-reference?format=txt", ""},
+		{"/tutorial/all?download=false", ""},
+		{"/tutorial/tui?download=true", ""},
+	}
+	for _, tt := range tests {
+		resp, _ := getPage(t, app, tt.path)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: status %d", tt.path, resp.StatusCode)
+		}
+		if got := resp.Header.Get(fiber.HeaderContentDisposition); got != tt.want {
+			t.Errorf("GET %s: Content-Disposition %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
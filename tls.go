@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// tlsVersions are the minimum versions TLS_MIN_VERSION accepts. TLS 1.0 and
+// 1.1 are deprecated (RFC 8996) and rejected.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSConfig builds the HTTPS configuration from TLS_CERT_FILE,
+// TLS_KEY_FILE, TLS_MIN_VERSION and TLS_CIPHER_SUITES. It returns nil when no
+// certificate is configured, meaning plain HTTP. Cipher suites are Go names
+// such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256; an empty list keeps Go's
+// defaults. Insecure suites are rejected, as are suites with a 1.3 minimum,
+// since TLS 1.3 suites are not configurable.
+func parseTLSConfig(certFile, keyFile, minVersion, cipherSuites string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("TLS_MIN_VERSION %q must be 1.2 or 1.3", minVersion)
+	}
+
+	var suites []uint16
+	for _, name := range splitList(cipherSuites) {
+		if cipherSuiteID(tls.InsecureCipherSuites(), name) != 0 {
+			return nil, fmt.Errorf("cipher suite %s is insecure", name)
+		}
+		id := cipherSuiteID(tls.CipherSuites(), name)
+		if id == 0 {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		suites = append(suites, id)
+	}
+	if len(suites) > 0 && version == tls.VersionTLS13 {
+		return nil, errors.New("TLS_CIPHER_SUITES has no effect with TLS_MIN_VERSION=1.3")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   version,
+		CipherSuites: suites,
+	}, nil
+}
+
+// cipherSuiteID returns the ID of the suite called name, or 0 when it is not
+// in suites.
+func cipherSuiteID(suites []*tls.CipherSuite, name string) uint16 {
+	i := slices.IndexFunc(suites, func(s *tls.CipherSuite) bool { return s.Name == name })
+	if i < 0 {
+		return 0
+	}
+	return suites[i].ID
+}
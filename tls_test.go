@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 and its key,
+// returning their paths.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// handshake connects to a TLS listener using at most maxVersion and returns
+// the negotiated version.
+func handshake(t *testing.T, config *tls.Config, maxVersion uint16) (uint16, error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln = tls.NewListener(ln, config)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10, MaxVersion: maxVersion})
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().Version, nil
+}
+
+func TestParseTLSConfigMinVersion(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	tests := []struct {
+		minVersion string
+		client     uint16
+		ok         bool
+	}{
+		{"1.2", tls.VersionTLS11, false},
+		{"1.2", tls.VersionTLS12, true},
+		{"1.2", tls.VersionTLS13, true},
+		{"1.3", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, true},
+	}
+	for _, tt := range tests {
+		config, err := parseTLSConfig(certFile, keyFile, tt.minVersion, "")
+		if err != nil {
+			t.Fatalf("TLS_MIN_VERSION=%s: %v", tt.minVersion, err)
+		}
+		version, err := handshake(t, config, tt.client)
+		if (err == nil) != tt.ok {
+			t.Errorf("TLS_MIN_VERSION=%s, client up to %s: error %v, want ok=%v", tt.minVersion, tls.VersionName(tt.client), err, tt.ok)
+		}
+		if err == nil && version != tt.client {
+			t.Errorf("TLS_MIN_VERSION=%s: negotiated %s, want %s", tt.minVersion, tls.VersionName(version), tls.VersionName(tt.client))
+		}
+	}
+}
+
+func TestParseTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	const suite = "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"
+
+	config, err := parseTLSConfig(certFile, keyFile, "1.2", suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.CipherSuites) != 1 || config.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("cipher suites %v, want only %s", config.CipherSuites, suite)
+	}
+
+	if config, err := parseTLSConfig("", "", "1.2", ""); config != nil || err != nil {
+		t.Errorf("no certificate: %v, %v; want plain HTTP", config, err)
+	}
+
+	invalid := []struct {
+		cert, key, minVersion, suites string
+	}{
+		{certFile, "", "1.2", ""},
+		{certFile, keyFile, "1.1", ""},
+		{certFile, keyFile, "1.2", "TLS_RSA_WITH_RC4_128_SHA"},
+		{certFile, keyFile, "1.2", "TLS_MADE_UP"},
+		{certFile, keyFile, "1.3", suite},
+		{keyFile, certFile, "1.2", ""},
+	}
+	for _, tt := range invalid {
+		if _, err := parseTLSConfig(tt.cert, tt.key, tt.minVersion, tt.suites); err == nil {
+			t.Errorf("parseTLSConfig(%q, %q, %q, %q): want an error", tt.cert, tt.key, tt.minVersion, tt.suites)
+		}
+	}
+}
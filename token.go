@@ -0,0 +1,29 @@
+package main
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requireToken guards a route with a shared token, accepted either as
+// "Authorization: Bearer <token>" or a ?token= query parameter. An empty
+// token leaves the route open.
+func requireToken(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return c.Next()
+		}
+
+		given := c.Query("token")
+		if auth := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+			given = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid or missing token")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/subtle"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// tutorialPage describes one tutorial guide served under /tutorial.
+type tutorialPage struct {
+	Slug     string
+	Path     string
+	Template string
+	// PlainText serves a text rendering to clients asking for text/plain.
+	PlainText bool
+	// Draft pages are unpublished: they are left out of the site map and the
+	// combined document, and only served with ?preview=PREVIEW_TOKEN.
+	Draft bool
+}
+
+var tutorialPages = []tutorialPage{
+	{Slug: "self-hosting", Path: "/tutorial/self-hosting", Template: "tutorial-self-hosting.html"},
+	{Slug: "cli-reference", Path: "/tutorial/cli-reference", Template: "tutorial-cli-reference.html", PlainText: true},
+	{Slug: "tui", Path: "/tutorial/tui", Template: "tutorial-tui.html"},
+}
+
+// canPreview reports whether the request carries ?preview=token. While token
+// is empty (PREVIEW_TOKEN unset) drafts are never served.
+func canPreview(c *fiber.Ctx, token string) bool {
+	given := c.Query("preview")
+	return token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// hideDraft answers 404 for a draft page unless the request can preview it.
+// Previews are marked as uncacheable and not to be indexed.
+func hideDraft(templates *assetCache, previewToken string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !canPreview(c, previewToken) {
+			return sendNotFound(c, templates)
+		}
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		c.Set("X-Robots-Tag", "noindex")
+		return c.Next()
+	}
+}
+
+func findTutorial(slug string) (tutorialPage, bool) {
+	for _, page := range tutorialPages {
+		if page.Slug == slug {
+			return page, true
+		}
+	}
+	return tutorialPage{}, false
+}
+
+var (
+	titleRe   = regexp.MustCompile(`(?is)<title>(.*?)</title>`)
+	bodyRe    = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	sectionRe = regexp.MustCompile(`(?i)<section\s+id="([^"]+)"`)
+	headingRe = regexp.MustCompile(`(?is)<h2[^>]*>(.*?)</h2>`)
+	tagRe     = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// tutorialSection is a top-level section of a tutorial page.
+type tutorialSection struct {
+	ID      string `json:"id"`
+	Heading string `json:"heading"`
+}
+
+// tutorialMeta is the metadata extracted from a tutorial template.
+type tutorialMeta struct {
+	Title    string            `json:"title"`
+	Sections []tutorialSection `json:"sections"`
+}
+
+// extractTutorialMeta pulls the page title and the id/heading of each
+// <section id="..."> out of a tutorial template.
+func extractTutorialMeta(content string) tutorialMeta {
+	meta := tutorialMeta{Sections: []tutorialSection{}}
+	if m := titleRe.FindStringSubmatch(content); m != nil {
+		meta.Title = textContent(m[1])
+	}
+
+	sections := sectionRe.FindAllStringSubmatchIndex(content, -1)
+	for i, loc := range sections {
+		end := len(content)
+		if i+1 < len(sections) {
+			end = sections[i+1][0]
+		}
+		section := tutorialSection{ID: content[loc[2]:loc[3]]}
+		if m := headingRe.FindStringSubmatch(content[loc[1]:end]); m != nil {
+			section.Heading = textContent(m[1])
+		}
+		meta.Sections = append(meta.Sections, section)
+	}
+	return meta
+}
+
+// textContent strips tags and entities from an HTML fragment.
+func textContent(fragment string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(tagRe.ReplaceAllString(fragment, " "))), " ")
+}
+
+// tutorialAPIHandler serves a tutorial's body HTML and metadata as JSON. Its
+// 404s are JSON too, whatever the client accepts.
+func tutorialAPIHandler(templates *assetCache, previewToken string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		page, ok := findTutorial(c.Params("slug"))
+		if !ok || (page.Draft && !canPreview(c, previewToken)) {
+			return sendJSONError(c, fiber.StatusNotFound, "Tutorial not found")
+		}
+
+		file, err := templates.get(page.Template)
+		if err != nil {
+			return sendJSONError(c, fiber.StatusNotFound, "Tutorial not found")
+		}
+
+		// The nonce belongs to a page response; API consumers apply their own CSP
+		body := string(stripCSPNonce(file.content))
+		if m := bodyRe.FindStringSubmatch(body); m != nil {
+			body = strings.TrimSpace(m[1])
+		}
+
+		meta := extractTutorialMeta(string(file.content))
+		return c.JSON(fiber.Map{
+			"slug":     page.Slug,
+			"path":     page.Path,
+			"url":      absoluteURL(page.Path),
+			"title":    meta.Title,
+			"sections": meta.Sections,
+			"html":     body,
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// guide is a tutorial template with two sections.
+func guide(title string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(`<!DOCTYPE html><html><head><title>` + title + ` - Knowledge Garden CLI</title></head>
+<body><main>
+<section id="install"><h2>📦 Install</h2><p>go install</p></section>
+<section id="usage"><h2>Usage &amp; flags</h2><p>run it</p></section>
+</main></body></html>`)}
+}
+
+// getJSON requests path as an API client would.
+func getJSON(t *testing.T, app *fiber.App, path string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+	return send(t, app, req)
+}
+
+func TestTutorialAPI(t *testing.T) {
+	app := newTestApp(t, testRouteConfig(fstest.MapFS{
+		"tutorial-tui.html": guide("TUI Guide"),
+	}, fstest.MapFS{}))
+
+	resp, body := getJSON(t, app, "/api/tutorials/tui")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("known slug: status %d: %s", resp.StatusCode, body)
+	}
+	var tutorial struct {
+		Slug     string            `json:"slug"`
+		Path     string            `json:"path"`
+		URL      string            `json:"url"`
+		Title    string            `json:"title"`
+		Sections []tutorialSection `json:"sections"`
+		HTML     string            `json:"html"`
+	}
+	if err := json.Unmarshal([]byte(body), &tutorial); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	if tutorial.Slug != "tui" || tutorial.Path != "/tutorial/tui" || tutorial.URL != "/tutorial/tui" {
+		t.Errorf("slug/path/url = %q %q %q", tutorial.Slug, tutorial.Path, tutorial.URL)
+	}
+	if tutorial.Title != "TUI Guide - Knowledge Garden CLI" {
+		t.Errorf("title %q", tutorial.Title)
+	}
+	want := []tutorialSection{{ID: "install", Heading: "📦 Install"}, {ID: "usage", Heading: "Usage & flags"}}
+	if len(tutorial.Sections) != len(want) || tutorial.Sections[0] != want[0] || tutorial.Sections[1] != want[1] {
+		t.Errorf("sections %+v, want %+v", tutorial.Sections, want)
+	}
+	if len(tutorial.HTML) == 0 || tutorial.HTML[:6] != "<main>" {
+		t.Errorf("html %q, want the page body", tutorial.HTML)
+	}
+
+	// Unknown and missing guides are JSON 404s whatever the client accepts
+	for _, path := range []string{"/api/tutorials/unknown", "/api/tutorials/self-hosting"} {
+		for _, accept := range []string{"", "*/*", "text/html", fiber.MIMEApplicationJSON} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if accept != "" {
+				req.Header.Set(fiber.HeaderAccept, accept)
+			}
+			resp, body := send(t, app, req)
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("GET %s, Accept %q: status %d, want 404", path, accept, resp.StatusCode)
+			}
+			if got := resp.Header.Get(fiber.HeaderContentType); got != fiber.MIMEApplicationJSON {
+				t.Errorf("GET %s, Accept %q: Content-Type %q, want application/json", path, accept, got)
+			}
+			if body != `{"error":"Tutorial not found"}` {
+				t.Errorf("GET %s, Accept %q: body %s", path, accept, body)
+			}
+		}
+	}
+}
+
+func TestDraftPreview(t *testing.T) {
+	saved := tutorialPages
+	tutorialPages = append([]tutorialPage(nil), saved...)
+	t.Cleanup(func() { tutorialPages = saved })
+	draft, _ := findTutorial("tui")
+	for i := range tutorialPages {
+		if tutorialPages[i].Slug == draft.Slug {
+			tutorialPages[i].Draft = true
+		}
+	}
+
+	templates := fstest.MapFS{"404.html": page("Not Found", "custom not found")}
+	for _, page := range tutorialPages {
+		templates[page.Template] = guide(page.Slug + " guide")
+	}
+	cfg := testRouteConfig(templates, fstest.MapFS{})
+	cfg.previewToken = "secret"
+	app := newTestApp(t, cfg)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{draft.Path, http.StatusNotFound},
+		{draft.Path + "?preview=wrong", http.StatusNotFound},
+		{draft.Path + "?preview=secret", http.StatusOK},
+		{"/api/tutorials/" + draft.Slug, http.StatusNotFound},
+		{"/api/tutorials/" + draft.Slug + "?preview=secret", http.StatusOK},
+		{"/tutorial/self-hosting", http.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, body := getPage(t, app, tt.path)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusNotFound && strings.Contains(body, "tui guide") {
+			t.Errorf("GET %s: 404 leaked the draft", tt.path)
+		}
+		if tt.path == draft.Path+"?preview=secret" {
+			if resp.Header.Get("X-Robots-Tag") != "noindex" || resp.Header.Get(fiber.HeaderCacheControl) != "no-store" {
+				t.Errorf("GET %s: preview not marked noindex and no-store", tt.path)
+			}
+		}
+	}
+
+	for _, path := range []string{"/sitemap", "/tutorial/all"} {
+		if _, body := getPage(t, app, path); strings.Contains(body, draft.Path) || strings.Contains(body, "tui guide") {
+			t.Errorf("%s lists the draft", path)
+		}
+	}
+}
+
+func TestEmbeddedTutorialsHaveSections(t *testing.T) {
+	templates, err := assetFS("templates", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, page := range tutorialPages {
+		content, err := fs.ReadFile(templates, page.Template)
+		if err != nil {
+			t.Errorf("%s: %v", page.Template, err)
+			continue
+		}
+		meta := extractTutorialMeta(string(content))
+		if len(meta.Sections) == 0 {
+			t.Errorf(`%s: no <section id="..."> sections, so it is missing from every table of contents`, page.Template)
+		}
+		for _, section := range meta.Sections {
+			if section.Heading == "" {
+				t.Errorf("%s: section %q has no <h2> heading", page.Template, section.ID)
+			}
+		}
+	}
+}